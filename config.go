@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	todos "github.com/go-do/todo"
+)
+
+const (
+	defaultTodoFile = "todos.txt"
+	appName         = "go-do"
+)
+
+// todoFile is the file every command works on, resolved by resolveTodoFile
+// before any command runs.
+var todoFile = defaultTodoFile
+
+// journal records the changes made by the running command.
+var journal *todos.Journal
+
+// config holds the settings of the config file, loaded on first use.
+var config map[string]string
+
+// readSettings parses the KEY=VALUE lines of file. Empty lines and lines
+// starting with # are skipped.
+func readSettings(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	settings := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		l := strings.TrimSpace(scanner.Text())
+		if !strings.Contains(l, "=") || strings.HasPrefix(l, "#") {
+			continue
+		}
+		kv := strings.SplitN(l, "=", 2)
+		settings[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return settings, scanner.Err()
+}
+
+// configPath returns the path of the config file:
+// $XDG_CONFIG_HOME/go-do/config, or ~/.config/go-do/config when
+// XDG_CONFIG_HOME isn't set.
+func configPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if len(dir) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, appName, "config")
+}
+
+// configValue returns the value of key in the config file.
+func configValue(key string) (string, bool) {
+	if config == nil {
+		config = make(map[string]string)
+		if path := configPath(); len(path) > 0 {
+			if settings, err := readSettings(path); err == nil {
+				config = settings
+			}
+		}
+	}
+	v, ok := config[key]
+	return v, ok && len(v) > 0
+}
+
+// setting returns the value of key, taken from the environment when it is
+// set there, otherwise from the config file.
+func setting(key string) (string, bool) {
+	if v, ok := os.LookupEnv(key); ok && len(v) > 0 {
+		return v, true
+	}
+	return configValue(key)
+}
+
+// expandHome replaces a leading ~ of path with the home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// resolveTodoFile returns the todo file to work on. The first one set wins:
+// the --file flag, the TODO_FILE or TODO_DIR environment variables, the
+// TODO_FILE or TODO_DIR settings of the config file, then todos.txt in the
+// current directory. TODO_DIR names the directory todos.txt is kept in.
+func resolveTodoFile(flag string) string {
+	if len(flag) > 0 {
+		return expandHome(flag)
+	}
+
+	lookups := []func(string) (string, bool){
+		func(key string) (string, bool) {
+			v, ok := os.LookupEnv(key)
+			return v, ok && len(v) > 0
+		},
+		configValue,
+	}
+	for _, lookup := range lookups {
+		if file, ok := lookup("TODO_FILE"); ok {
+			return expandHome(file)
+		}
+		if dir, ok := lookup("TODO_DIR"); ok {
+			return filepath.Join(expandHome(dir), defaultTodoFile)
+		}
+	}
+	return defaultTodoFile
+}
+
+// newJournal returns the journal of fname, kept next to it, recording the
+// changes made by command. Its size is the TODO_JOURNAL_SIZE setting when
+// there is one.
+func newJournal(fname, command string) *todos.Journal {
+	name := filepath.Join(filepath.Dir(fname), "."+filepath.Base(fname)+".journal")
+	j := todos.NewJournal(name, command)
+	if size, ok := setting("TODO_JOURNAL_SIZE"); ok {
+		if n, err := strconv.Atoi(size); err == nil && n > 0 {
+			j.Limit = n
+		}
+	}
+	return j
+}
+
+// templateText returns the output template stored in the TODO_FORMAT_<NAME>
+// setting when format is a name, e.g. short for TODO_FORMAT_SHORT,
+// otherwise format itself.
+func templateText(format string) string {
+	if strings.Contains(format, "{{") {
+		return format
+	}
+	name := strings.ToUpper(strings.ReplaceAll(format, "-", "_"))
+	if text, ok := setting("TODO_FORMAT_" + name); ok {
+		return text
+	}
+	return format
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -28,33 +31,21 @@ func init() {
 // Read variables set in a .env file and export them as environment variables.
 // The implementation is very naive, but we don't really need a dotenv config package right now.
 func setEnvVars(file string) {
-	f, err := os.Open(file)
+	settings, err := readSettings(file)
 	if err != nil {
-		log.Printf("Couldn't open %s file\n", file)
-		fmt.Printf("Couldn't open %s file\n", file)
+		// go-do runs from any directory, most of which have no .env file
+		log.Printf("Couldn't read %s file: %v\n", file, err)
 		return
 	}
 
-	body, err := io.ReadAll(f)
-	if err != nil {
-		log.Println("Failed to read file data")
-		fmt.Println("Failed to read file data")
-		return
-	}
-	lines := strings.Split(string(body), "\n")
-
-	for _, l := range lines {
-		if !strings.Contains(l, "=") || strings.HasPrefix(l, "#") {
-			continue
-		}
-		line := strings.Split(l, "=")
-		os.Setenv(line[0], line[1])
+	for k, v := range settings {
+		os.Setenv(k, v)
 	}
 }
 
-func confirmDeletion(fname, result string) error {
+func confirmDeletion(s todos.Store, t *todos.Todo) error {
 	pr := promptui.Prompt{
-		Label:     fmt.Sprintf("Delete %q", result),
+		Label:     fmt.Sprintf("Delete %q", t.Original),
 		IsConfirm: true,
 	}
 
@@ -62,37 +53,181 @@ func confirmDeletion(fname, result string) error {
 		fmt.Printf("Prompt failed %v\n", err)
 		return err
 	}
-	fmt.Printf("You deleted %q\n", result)
 
-	// File is somehow getting "consumed" when reading it to build the
-	// selection list, so we have to re-open it here
-	f, err := os.Open(fname)
+	if err := s.Delete(t); err != nil {
+		return err
+	}
+	fmt.Printf("You deleted %q\n", t.Original)
+	return nil
+}
+
+// reportParseErrors prints every parse problem contained in err, each one
+// followed by the offending line and a caret under the bad column. Any other
+// error is returned.
+func reportParseErrors(err error) error {
+	var errs todos.ParseErrors
+	var perr *todos.ParseError
+	switch {
+	case errors.As(err, &errs):
+	case errors.As(err, &perr):
+		errs = todos.ParseErrors{perr}
+	default:
+		return err
+	}
+
+	for _, e := range errs {
+		fmt.Fprintf(os.Stderr, "%s\n%s\n", e, e.Caret())
+	}
+	return nil
+}
+
+// reportedError is an error already printed by reportParseErrors, which
+// main doesn't print again.
+type reportedError struct {
+	error
+}
+
+func (e reportedError) Unwrap() error {
+	return e.error
+}
+
+// reported prints the parse problems of err and returns it marked as
+// reported. Any other error is returned as it is, for main to print.
+func reported(err error) error {
+	if reportParseErrors(err) != nil {
+		return err
+	}
+	return reportedError{err}
+}
+
+// addRecurrences adds the next occurrences of recurring todos to the todo
+// file, giving them a persistent id.
+func addRecurrences(next []*todos.Todo) error {
+	if len(next) == 0 {
+		return nil
+	}
+
+	store := todoStore()
+	all, err := loadTodos(store)
 	if err != nil {
-		fmt.Println("couldn't open file")
 		return err
 	}
-	defer f.Close()
+	id := todos.NextID(all)
+	for i, t := range next {
+		t.SetValue(todos.IDKey, strconv.Itoa(id+i))
+	}
+
+	if err := store.Append(next...); err != nil {
+		return fmt.Errorf("failed to add the next occurrences of todos: %w", err)
+	}
+	for _, t := range next {
+		todos.PrintLine(t.Line, t.Original)
+	}
+	return nil
+}
+
+// newImporter returns the importer of format. The columns of csv and tsv
+// files are mapped by mappings, or the TODO_IMPORT_MAP setting when there
+// are none, see todos.ParseColumns.
+func newImporter(format, mappings string) (todos.Importer, error) {
+	format = strings.ToLower(format)
+	if format != "csv" && format != "tsv" {
+		if len(mappings) > 0 {
+			return nil, fmt.Errorf("column mappings only apply to csv and tsv files, not %s", format)
+		}
+		return todos.ImporterFunc(func(r io.Reader) ([]*todos.Todo, error) {
+			return todos.Import(r, format)
+		}), nil
+	}
 
-	todos.DeleteFirst(f, result)
+	importer := todos.CSVImporter{}
+	if format == "tsv" {
+		importer.Comma = '\t'
+	}
+	if len(mappings) == 0 {
+		mappings, _ = setting("TODO_IMPORT_MAP")
+	}
+	if len(mappings) > 0 {
+		columns, err := todos.ParseColumns(mappings)
+		if err != nil {
+			return nil, err
+		}
+		importer.Columns = columns
+	}
+	return importer, nil
+}
+
+// doneFile returns the name of the file the complete todos of fname are
+// archived to: the TODO_DONE_FILE setting if there is one, otherwise done.txt
+// next to fname.
+func doneFile(fname string) string {
+	if name, ok := setting("TODO_DONE_FILE"); ok {
+		return expandHome(name)
+	}
+	return filepath.Join(filepath.Dir(fname), "done.txt")
+}
+
+// autoArchive reports whether complete todos are archived right after
+// being marked as done, which is enabled by the TODO_AUTO_ARCHIVE setting.
+func autoArchive() bool {
+	value, _ := setting("TODO_AUTO_ARCHIVE")
+	enabled, _ := strconv.ParseBool(value)
+	return enabled
+}
+
+// archiveTodos moves the complete todos of fname to its done file.
+func archiveTodos(fname string) error {
+	archived, err := todos.Archive(fileStore(fname), fileStore(doneFile(fname)))
+	if err != nil {
+		return fmt.Errorf("failed to archive todos: %w", err)
+	}
+	for _, t := range archived {
+		fmt.Printf("Archived %q\n", t.Original)
+	}
 	return nil
 }
 
 func main() {
 	var tag, value string
 	app := &cli.App{
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "file", Usage: "work on the todos of `FILE` instead of the configured todo file"},
+		},
+		Before: func(c *cli.Context) error {
+			todoFile = resolveTodoFile(c.String("file"))
+			journal = newJournal(todoFile, strings.Join(c.Args().Slice(), " "))
+			return nil
+		},
 		Commands: []*cli.Command{{
 			Name:    "create",
 			Aliases: []string{"c"},
 			Usage:   "`Todo` value based on todo.txt format",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "no-id", Usage: "don't add a persistent id: tag to the todo"},
+			},
 			Action: func(c *cli.Context) error {
 				if c.Args().Len() > 0 {
-					t, err := todos.Parse(c.Args().First())
-					log.Println(t.Original)
+					t, err := todos.Parse(todos.ExpandDates(c.Args().First(), time.Now()))
 					if err != nil {
-						return err
+						return reported(err)
 					}
 
-					todos.AddToFile(t)
+					if _, ok := t.Value(todos.IDKey); !ok && !c.Bool("no-id") {
+						all, err := loadTodos(todoStore())
+						if err != nil {
+							return err
+						}
+
+						key := todos.IDKey
+						id := strconv.Itoa(todos.NextID(all))
+						t.Description.Tags = append(t.Description.Tags, todos.Tag{TagType: todos.KeyValue, Key: &key, Value: id})
+						t.Original = t.Format()
+					}
+					log.Println(t.Original)
+
+					if err := todoStore().Append(t); err != nil {
+						return fmt.Errorf("failed to add todo: %w", err)
+					}
 				} else {
 					log.Println("Couldn't parse todo.")
 				}
@@ -100,15 +235,26 @@ func main() {
 			},
 		},
 			{
-				Name:  "show",
-				Usage: "Show all saved todos",
+				Name:      "show",
+				Usage:     "Show all saved todos, or the ones matching a query, e.g. show '+work and pri>=B and not done'",
+				ArgsUsage: "[<query>]",
 				Flags: []cli.Flag{
 					&cli.StringFlag{Name: "tag", Aliases: []string{"t"}, Destination: &tag},
 					&cli.StringFlag{Name: "value", Aliases: []string{"v"}, Destination: &value},
 					&cli.BoolFlag{Name: "complete", Aliases: []string{"c", "done", "d"}},
 					&cli.BoolFlag{Name: "incomplete", Aliases: []string{"inc", "todo", "td"}},
+					&cli.BoolFlag{Name: "archived", Aliases: []string{"a"}, Usage: "with --complete, also show the todos archived to the done file"},
+					&cli.BoolFlag{Name: "all", Usage: "also show the todos whose threshold date (t: tag) is still to come"},
+					&cli.StringFlag{Name: "due", Usage: "show the todos due `when`: overdue, today, this-week, a date or a range of dates, e.g. today..+7d"},
+					&cli.StringFlag{Name: "sort", Aliases: []string{"s"}, Usage: "sort todos by comma separated `keys`, each followed by :asc or :desc, e.g. priority,due:desc"},
+					&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Value: "text", Usage: "print todos as text, json, jsonl, csv or tsv"},
+					&cli.StringFlag{Name: "format", Aliases: []string{"F"}, Usage: "print each todo with a text/template `template`, e.g. '{{.ID}} {{.Description.Text}}', or the name of one set in the config as TODO_FORMAT_<NAME>"},
 				},
 				Action: func(c *cli.Context) error {
+					if c.IsSet("output") && c.IsSet("format") {
+						return errors.New("--output and --format can't be used together, --format prints text")
+					}
+					match := func(*todos.Todo) bool { return true }
 					if len(tag) > 0 {
 						if len(value) <= 0 {
 							fmt.Println("you have to provide a value when passing in a tag")
@@ -117,66 +263,552 @@ func main() {
 
 						switch strings.ToLower(tag) {
 						case strings.ToLower(todos.Project.String()):
-							todos.PrintByTag(todos.Project, value)
+							match = todos.TagValueFilter(todos.Project, value)
 						case strings.ToLower(todos.Context.String()):
-							todos.PrintByTag(todos.Context, value)
+							match = todos.TagValueFilter(todos.Context, value)
 						case strings.ToLower(todos.KeyValue.String()):
-							todos.PrintByKVTag(value)
+							match = todos.KeyFilter(value)
 						default:
 							return errors.New("viable tag values are one of project, context or keyvalue")
 						}
-					} else if c.Bool("complete") {
-						f, err := os.Open("todos-copy.txt")
+					}
+					if c.Args().Len() > 0 {
+						query, err := todos.Compile(strings.Join(c.Args().Slice(), " "))
+						if err != nil {
+							return reported(err)
+						}
+						tagged := match
+						match = func(t *todos.Todo) bool { return tagged(t) && query(t) }
+					}
+					if c.IsSet("due") {
+						due, err := todos.DueFilter(c.String("due"), time.Now())
 						if err != nil {
-							return errors.New("couldn't open file")
+							return err
 						}
-						defer f.Close()
+						query := match
+						match = func(t *todos.Todo) bool { return due(t) && query(t) }
+					}
+					if !c.Bool("all") {
+						query := match
+						match = func(t *todos.Todo) bool { return !t.Hidden(time.Now()) && query(t) }
+					}
+					if c.Bool("complete") || c.Bool("incomplete") {
+						query := match
+						match = func(t *todos.Todo) bool {
+							return t.Done == c.Bool("complete") && query(t)
+						}
+					}
 
-						lines, _ := todos.GetFromFile(f)
-						for _, l := range todos.GetCompleteLines(lines) {
-							fmt.Println(l)
+					var keys []todos.SortKey
+					if c.IsSet("sort") {
+						var err error
+						if keys, err = todos.ParseSortKeys(c.String("sort")); err != nil {
+							return err
 						}
-					} else if c.Bool("incomplete") {
-						f, err := os.Open("todos-copy.txt")
+					}
+
+					all, err := loadTodos(todoStore())
+					if err != nil {
+						return err
+					}
+					shown := selectTodos(all, match, keys)
+
+					if c.Bool("complete") && c.Bool("archived") {
+						archived, err := loadTodos(todos.NewFileStore(doneFile(todoFile)))
 						if err != nil {
-							return errors.New("couldn't open file")
+							return err
+						}
+						// Archived todos have no ID, they are listed with 0
+						for _, t := range selectTodos(archived, match, keys) {
+							t.Line = 0
+							shown = append(shown, t)
 						}
-						defer f.Close()
+					}
 
-						lines, _ := todos.GetFromFile(f)
-						for _, l := range todos.GetIncompleteLines(lines) {
-							fmt.Println(l)
+					if output := c.String("output"); output != "text" {
+						return todos.WriteRecords(os.Stdout, output, shown)
+					}
+					if c.IsSet("format") {
+						tmpl, err := todos.NewTemplate(templateText(c.String("format")), time.Now())
+						if err != nil {
+							return fmt.Errorf("bad format: %w", err)
 						}
-					} else {
-						todos.PrintAll()
+						return todos.WriteTemplate(os.Stdout, tmpl, shown, time.Now())
+					}
+					for _, t := range shown {
+						printTodo(t.Line, t)
 					}
 					return nil
 				},
 			},
 			{
-				Name:    "delete",
-				Aliases: []string{"d"},
-				Usage:   "Delete a todo",
+				Name:      "export",
+				Aliases:   []string{"e"},
+				Usage:     "Export all todos, or the ones matching a query, to a file or the standard output",
+				ArgsUsage: "[<query>]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "format", Value: "txt", Usage: "export todos as " + strings.Join(todos.Exporters(), ", ")},
+					&cli.StringFlag{Name: "name", Aliases: []string{"n"}, Usage: "write the todos to `FILE` instead of the standard output"},
+				},
 				Action: func(c *cli.Context) error {
+					match := func(*todos.Todo) bool { return true }
 					if c.Args().Len() > 0 {
-						if len(c.Args().First()) < 1 {
-							log.Fatal("passed empty description")
-							return errors.New("Todo description cannot be empty.")
+						query, err := todos.Compile(strings.Join(c.Args().Slice(), " "))
+						if err != nil {
+							return reported(err)
+						}
+						match = query
+					}
+
+					all, err := loadTodos(todoStore())
+					if err != nil {
+						return err
+					}
+
+					selected := selectTodos(all, match, nil)
+					var b bytes.Buffer
+					if err := todos.Export(&b, c.String("format"), selected); err != nil {
+						return err
+					}
+					name := c.String("name")
+					if len(name) == 0 {
+						_, err := b.WriteTo(os.Stdout)
+						return err
+					}
+					if err := os.WriteFile(expandHome(name), b.Bytes(), 0644); err != nil {
+						return fmt.Errorf("failed to export todos: %w", err)
+					}
+					fmt.Printf("Exported %d todos to %s\n", len(selected), name)
+					return nil
+				},
+			},
+			{
+				Name:      "import",
+				Usage:     "Add the todos of a file in another format, skipping the ones already in the todo file",
+				ArgsUsage: "<file>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "format", Value: "csv", Usage: "read todos as " + strings.Join(todos.Importers(), ", ")},
+					&cli.StringFlag{Name: "map", Aliases: []string{"m"}, Usage: "map csv or tsv columns to fields with comma separated header=field `mappings`, e.g. Task=description,Deadline=due"},
+					&cli.BoolFlag{Name: "no-id", Usage: "don't add a persistent id: tag to the todos"},
+				},
+				Action: func(c *cli.Context) error {
+					if c.Args().Len() != 1 {
+						return errors.New("please, provide the file to import")
+					}
+					importer, err := newImporter(c.String("format"), c.String("map"))
+					if err != nil {
+						return err
+					}
+
+					f, err := os.Open(expandHome(c.Args().First()))
+					if err != nil {
+						return err
+					}
+					defer f.Close()
+
+					imported, err := importer.Import(f)
+					var bad todos.ParseErrors
+					errors.As(err, &bad)
+					if err := reportParseErrors(err); err != nil {
+						return fmt.Errorf("couldn't import todos: %w", err)
+					}
+
+					store := todoStore()
+					all, err := loadTodos(store)
+					if err != nil {
+						return err
+					}
+					updated, added := todos.Merge(all, imported)
+					if !c.Bool("no-id") {
+						// imported ids are kept, unless the todo file already uses them
+						ids := make(map[string]bool, len(all))
+						for _, t := range all {
+							if v, ok := t.Value(todos.IDKey); ok {
+								ids[v] = true
+							}
+						}
+						id := todos.NextID(append(all, added...))
+						for _, t := range added {
+							if v, ok := t.Value(todos.IDKey); ok && !ids[v] {
+								ids[v] = true
+								continue
+							}
+							t.SetValue(todos.IDKey, strconv.Itoa(id))
+							id++
+						}
+					}
+
+					// todos with the id of a todo of the file, e.g. changed in
+					// a calendar app, take its place
+					if len(updated) > 0 {
+						if err := store.Update(updated...); err != nil {
+							return fmt.Errorf("failed to update todos: %w", err)
+						}
+						for _, t := range updated {
+							todos.PrintLine(t.Line, t.Original)
+						}
+					}
+					if err := store.Append(added...); err != nil {
+						return fmt.Errorf("failed to import todos: %w", err)
+					}
+					for _, t := range added {
+						todos.PrintLine(t.Line, t.Original)
+					}
+					fmt.Printf("Imported %d todos, updated %d, skipped %d duplicates and %d bad entries\n",
+						len(added), len(updated), len(imported)-len(added)-len(updated), len(bad))
+					return nil
+				},
+			},
+			{
+				Name:      "delete",
+				Aliases:   []string{"d"},
+				Usage:     "Delete todos by their ID, the number shown next to them by show or their id: tag",
+				ArgsUsage: "<id>...",
+				Flags:     selectFlags(),
+				Action: func(c *cli.Context) error {
+					sel := newSelection(c, c.Args().Slice())
+					if sel.empty() || (c.Args().Len() > 0 && len(c.Args().First()) < 1) {
+						log.Println("Couldn't find todo: empty ID")
+						return errors.New("please, provide the ID of the todo to be deleted")
+					}
+
+					store := todoStore()
+					all, err := loadTodos(store)
+					if err != nil {
+						return err
+					}
+
+					found, err := findTodos(all, sel)
+					if err != nil {
+						return err
+					}
+
+					if err := store.Delete(found...); err != nil {
+						return fmt.Errorf("failed to delete todos: %w", err)
+					}
+					for _, t := range found {
+						fmt.Printf("You deleted %q\n", t.Original)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "do",
+				Usage:     "Mark todos as done, stamping today's date as their completion date",
+				ArgsUsage: "<id>...",
+				Flags:     selectFlags(),
+				Action: func(c *cli.Context) error {
+					var next []*todos.Todo
+					// a bad rec: tag doesn't keep its todo from being done
+					var badRecurrences []error
+					err := editAction(func(t *todos.Todo) (bool, error) {
+						if t.Done {
+							fmt.Printf("Todo %s is already done\n", t.ID())
+							return false, nil
+						}
+						t.Complete(time.Now())
+
+						n, ok, err := t.Recur(time.Now())
+						if err != nil {
+							badRecurrences = append(badRecurrences, err)
+							return true, nil
+						}
+						if ok {
+							next = append(next, n)
+						}
+						return true, nil
+					})(c)
+					if err != nil {
+						return err
+					}
+					for _, err := range badRecurrences {
+						fmt.Fprintf(os.Stderr, "warning: %v, its next occurrence wasn't added\n", err)
+					}
+					if err := addRecurrences(next); err != nil || !autoArchive() {
+						return err
+					}
+					return archiveTodos(todoFile)
+				},
+			},
+			{
+				Name:  "sort",
+				Usage: "Rewrite the todo file with its todos sorted, complete todos last by default, removing blank lines",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "by", Aliases: []string{"b"}, Value: "done,priority,due,created", Usage: "sort todos by comma separated `keys`, each followed by :asc or :desc, e.g. priority,est:desc"},
+				},
+				Action: func(c *cli.Context) error {
+					keys, err := todos.ParseSortKeys(c.String("by"))
+					if err != nil {
+						return err
+					}
+
+					store := todoStore()
+					all, err := store.Load()
+					if err != nil {
+						if reportParseErrors(err) != nil {
+							return err
+						}
+						fmt.Fprintln(os.Stderr, "couldn't sort todos, fix the lines that can't be parsed first")
+						return reportedError{err}
+					}
+
+					todos.Sort(all, keys)
+					if err := store.Replace(all...); err != nil {
+						return fmt.Errorf("failed to sort todos: %w", err)
+					}
+					for _, t := range all {
+						todos.PrintLine(t.Line, t.Original)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "archive",
+				Usage: "Move complete todos to the done file (done.txt next to the todo file, or TODO_DONE_FILE)",
+				Action: func(c *cli.Context) error {
+					return archiveTodos(todoFile)
+				},
+			},
+			{
+				Name:      "undo",
+				Usage:     "Undo the last change to the todos, or mark the given done todos as not done, restoring their priority",
+				ArgsUsage: "[<id>...]",
+				Flags:     selectFlags(),
+				Action: func(c *cli.Context) error {
+					if newSelection(c, c.Args().Slice()).empty() {
+						e, err := journal.Undo()
+						if err != nil {
+							return err
+						}
+						fmt.Printf("Undid %q\n", e.Command)
+						return nil
+					}
+
+					return editAction(func(t *todos.Todo) (bool, error) {
+						if !t.Done {
+							fmt.Printf("Todo %s is not done\n", t.ID())
+							return false, nil
+						}
+						t.Reopen()
+						return true, nil
+					})(c)
+				},
+			},
+			{
+				Name:  "redo",
+				Usage: "Redo the last change undone by undo",
+				Action: func(c *cli.Context) error {
+					e, err := journal.Redo()
+					if err != nil {
+						return err
+					}
+					fmt.Printf("Redid %q\n", e.Command)
+					return nil
+				},
+			},
+			{
+				Name:  "history",
+				Usage: "List the last changes to the todos, newest first, which undo and redo step through",
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "limit", Aliases: []string{"n"}, Value: 10, Usage: "list at most `N` changes"},
+				},
+				Action: func(c *cli.Context) error {
+					entries, position, err := journal.Entries()
+					if err != nil {
+						return err
+					}
+
+					for i := len(entries) - 1; i >= 0 && len(entries)-i <= c.Int("limit"); i-- {
+						e := entries[i]
+						state := ""
+						if i >= position {
+							state = " (undone)"
+						}
+						fmt.Printf("%d %s %s%s\n", e.ID, e.Time.Format("2006-01-02 15:04"), e.Command, state)
+						for _, ch := range e.Changes {
+							if filepath.Base(ch.File) != filepath.Base(todoFile) {
+								fmt.Printf("\t%s:\n", ch.File)
+							}
+							for _, l := range ch.Unified() {
+								fmt.Printf("\t%s\n", l)
+							}
+						}
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "pri",
+				Aliases:   []string{"p"},
+				Usage:     "Set the priority of todos",
+				ArgsUsage: "<id>... <A-Z>",
+				Flags:     selectFlags(),
+				Action: func(c *cli.Context) error {
+					if c.Args().Len() < 1 {
+						return errors.New("please, provide the IDs of the todos followed by a priority (A-Z)")
+					}
+					args := c.Args().Slice()
+					priority := strings.ToUpper(args[len(args)-1])
+					if !todos.ValidPriority(priority) {
+						return fmt.Errorf("bad priority value %q, expected a letter (A-Z)", args[len(args)-1])
+					}
+
+					return editAndPrint(newSelection(c, args[:len(args)-1]), func(t *todos.Todo) (bool, error) {
+						if t.Done {
+							fmt.Printf("Todo %s is done\n", t.ID())
+							return false, nil
+						}
+						if t.Priority != nil && *t.Priority == priority {
+							return false, nil
+						}
+						return true, t.SetPriority(priority)
+					})
+				},
+			},
+			{
+				Name:      "snooze",
+				Usage:     "Hide todos until a date, or push back the date they are hidden until, e.g. snooze 3 1w",
+				ArgsUsage: "<id>... <duration|date>",
+				Flags:     selectFlags(),
+				Action: func(c *cli.Context) error {
+					if c.Args().Len() < 1 {
+						return errors.New("please, provide the IDs of the todos followed by a duration or a date")
+					}
+					args := c.Args().Slice()
+					value := args[len(args)-1]
+
+					return editAndPrint(newSelection(c, args[:len(args)-1]), func(t *todos.Todo) (bool, error) {
+						if t.Done {
+							fmt.Printf("Todo %s is done\n", t.ID())
+							return false, nil
 						}
+						return true, t.Snooze(value, time.Now())
+					})
+				},
+			},
+			{
+				Name:      "depri",
+				Aliases:   []string{"dp"},
+				Usage:     "Remove the priority of todos",
+				ArgsUsage: "<id>...",
+				Flags:     selectFlags(),
+				Action: editAction(func(t *todos.Todo) (bool, error) {
+					if t.Priority == nil {
+						return false, nil
+					}
+					t.RemovePriority()
+					return true, nil
+				}),
+			},
+			{
+				Name:      "bump",
+				Usage:     "Raise the priority of todos by one letter, e.g. from B to A",
+				ArgsUsage: "<id>...",
+				Flags:     selectFlags(),
+				Action: editAction(func(t *todos.Todo) (bool, error) {
+					if t.Done {
+						fmt.Printf("Todo %s is done\n", t.ID())
+						return false, nil
+					}
+					if !t.Bump() {
+						fmt.Printf("Todo %s already has the highest priority\n", t.ID())
+						return false, nil
+					}
+					return true, nil
+				}),
+			},
+			{
+				Name:      "lower",
+				Usage:     "Lower the priority of todos by one letter, e.g. from A to B",
+				ArgsUsage: "<id>...",
+				Flags:     selectFlags(),
+				Action: editAction(func(t *todos.Todo) (bool, error) {
+					if t.Done {
+						fmt.Printf("Todo %s is done\n", t.ID())
+						return false, nil
+					}
+					return t.Lower(), nil
+				}),
+			},
+			{
+				Name:      "replace",
+				Usage:     "Replace a todo with a new todo.txt line",
+				ArgsUsage: "<id> <todo>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "text", Aliases: []string{"x"}, Usage: "find the todo by part of its description instead of its ID"},
+				},
+				Action: rewriteTodo(func(t todos.Todo, text string) (*todos.Todo, error) {
+					return t.Replace(text)
+				}),
+			},
+			{
+				Name:      "append",
+				Aliases:   []string{"a"},
+				Usage:     "Add text to the end of a todo's description",
+				ArgsUsage: "<id> <text>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "text", Aliases: []string{"x"}, Usage: "find the todo by part of its description instead of its ID"},
+				},
+				Action: rewriteTodo(func(t todos.Todo, text string) (*todos.Todo, error) {
+					return t.Append(text)
+				}),
+			},
+			{
+				Name:      "prepend",
+				Aliases:   []string{"prep"},
+				Usage:     "Add text to the start of a todo's description",
+				ArgsUsage: "<id> <text>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "text", Aliases: []string{"x"}, Usage: "find the todo by part of its description instead of its ID"},
+				},
+				Action: rewriteTodo(func(t todos.Todo, text string) (*todos.Todo, error) {
+					return t.Prepend(text)
+				}),
+			},
+			{
+				Name:      "edit",
+				Usage:     "Edit a todo interactively, starting from its current line",
+				ArgsUsage: "<id>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "text", Aliases: []string{"x"}, Usage: "find the todo by part of its description instead of its ID"},
+				},
+				Action: func(c *cli.Context) error {
+					if c.Args().Len() != 1 {
+						return errors.New("please, provide the ID of the todo")
+					}
 
-						f, err := os.Open("todos-copy.txt")
+					sel := selection{refs: c.Args().Slice(), byText: c.Bool("text")}
+					edited, err := editTodos(todoStore(), sel, func(t *todos.Todo) (bool, error) {
+						prompt := promptui.Prompt{
+							Label:     "Todo",
+							Default:   t.Original,
+							AllowEdit: true,
+							Validate: func(line string) error {
+								_, err := todos.Parse(line)
+								return err
+							},
+						}
+						line, err := prompt.Run()
 						if err != nil {
-							return errors.New("couldn't open file")
+							return false, err
 						}
-						defer f.Close()
-						err = todos.DeleteFirst(f, c.Args().First())
+						if line == t.Original {
+							return false, nil
+						}
+
+						replaced, err := t.Replace(line)
 						if err != nil {
-							return fmt.Errorf("failed to delete todo with text: %q", c.Args().First())
+							return false, err
 						}
-						fmt.Printf("You deleted %q", c.Args().First())
-					} else {
-						log.Println("Couldn't parse todo: empty description")
-						return errors.New("please, provide a description for the todo to be deleted")
+						*t = *replaced
+						return true, nil
+					})
+					if err != nil {
+						return err
+					}
+
+					for _, t := range edited {
+						todos.PrintLine(t.Line, t.Original)
 					}
 					return nil
 				},
@@ -186,22 +818,56 @@ func main() {
 				Aliases: []string{"ds"},
 				Usage:   "Select a todo to delete by listing all todos",
 				Action: func(c *cli.Context) error {
-					fname := "todos-copy.txt"
-					f, err := os.Open(fname)
+					store := todoStore()
+					all, err := loadTodos(store)
 					if err != nil {
-						return errors.New("couldn't open file")
+						return err
 					}
-					defer f.Close()
 
-					lines, _ := todos.GetFromFile(f)
+					items := make([]string, 0, len(all))
+					for _, t := range all {
+						items = append(items, t.Original)
+					}
 
 					prompt := promptui.Select{
-						Size:  len(lines),
+						Size:  len(items),
 						Label: "Select Todo",
-						Items: lines,
+						Items: items,
+					}
+					i, _, err := prompt.Run()
+					if err != nil {
+						return err
+					}
+					return confirmDeletion(store, all[i])
+				},
+			},
+			{
+				Name:      "open",
+				Usage:     "List the links of todos",
+				ArgsUsage: "<id>...",
+				Flags:     selectFlags(),
+				Action: func(c *cli.Context) error {
+					sel := newSelection(c, c.Args().Slice())
+					if sel.empty() {
+						return errors.New("please, provide the ID of the todo")
+					}
+
+					all, err := loadTodos(todoStore())
+					if err != nil {
+						return err
+					}
+
+					found, err := findTodos(all, sel)
+					if err != nil {
+						return err
+					}
+
+					for _, t := range found {
+						todos.PrintLine(t.Line, t.Original)
+						for _, l := range t.Links {
+							fmt.Printf("\t%s\n", l)
+						}
 					}
-					_, result, _ := prompt.Run()
-					confirmDeletion(fname, result)
 					return nil
 				},
 			},
@@ -227,6 +893,9 @@ func main() {
 
 	err := app.Run(os.Args)
 	if err != nil {
+		if !errors.As(err, &reportedError{}) {
+			fmt.Fprintln(os.Stderr, err)
+		}
 		log.Fatal(err)
 	}
 }
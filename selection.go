@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	todos "github.com/go-do/todo"
+	"github.com/urfave/cli/v2"
+)
+
+// selection describes the todos a command works on: the todos refs point
+// to and, when a filter is given, every todo it matches.
+type selection struct {
+	// IDs of todos, or parts of their description when byText is set.
+	refs   []string
+	byText bool
+	filter string
+}
+
+// selectFlags returns the flags of commands working on a selection of todos.
+func selectFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{Name: "text", Aliases: []string{"x"}, Usage: "find each todo by part of its description instead of its ID"},
+		&cli.StringFlag{Name: "filter", Aliases: []string{"f"}, Usage: "select every todo matching the query `expression`, e.g. \"+work and not done\""},
+	}
+}
+
+func newSelection(c *cli.Context, refs []string) selection {
+	return selection{refs: refs, byText: c.Bool("text"), filter: c.String("filter")}
+}
+
+func (s selection) empty() bool {
+	return len(s.refs) == 0 && len(s.filter) == 0
+}
+
+// todoStore returns the store of the todo file commands work on.
+func todoStore() todos.Store {
+	return fileStore(todoFile)
+}
+
+// fileStore returns the store of fname, whose changes are journaled.
+func fileStore(fname string) *todos.FileStore {
+	s := todos.NewFileStore(fname)
+	s.Journal = journal
+	return s
+}
+
+// loadTodos returns every todo of s. Lines that couldn't be parsed are
+// reported and skipped.
+func loadTodos(s todos.Store) ([]*todos.Todo, error) {
+	all, err := s.Load()
+	if err := reportParseErrors(err); err != nil {
+		return nil, fmt.Errorf("couldn't load todos: %w", err)
+	}
+	return all, nil
+}
+
+// findTodos resolves the todos of a selection, in the order they are
+// referenced, followed by the todos matching its filter.
+func findTodos(all []*todos.Todo, sel selection) ([]*todos.Todo, error) {
+	found := make([]*todos.Todo, 0, len(sel.refs))
+	for _, ref := range sel.refs {
+		var t *todos.Todo
+		var err error
+		if sel.byText {
+			t, err = todos.FindByText(all, ref)
+		} else {
+			t, err = todos.Find(all, ref)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if !todos.Contains(found, t) {
+			found = append(found, t)
+		}
+	}
+
+	if len(sel.filter) > 0 {
+		match, err := todos.Compile(sel.filter)
+		if err != nil {
+			return nil, reported(err)
+		}
+		for _, t := range all {
+			if match(t) && !todos.Contains(found, t) {
+				found = append(found, t)
+			}
+		}
+	}
+	return found, nil
+}
+
+// selectTodos returns the todos match selects, sorted by keys.
+func selectTodos(all []*todos.Todo, match todos.Filter, keys []todos.SortKey) []*todos.Todo {
+	selected := make([]*todos.Todo, 0, len(all))
+	for _, t := range all {
+		if match(t) {
+			selected = append(selected, t)
+		}
+	}
+	todos.Sort(selected, keys)
+	return selected
+}
+
+// printTodo prints the line of a todo prefixed with n, marking it when it
+// is overdue.
+func printTodo(n int, t *todos.Todo) {
+	if t.Overdue(time.Now()) {
+		todos.PrintLine(n, t.Original+" (overdue)")
+		return
+	}
+	todos.PrintLine(n, t.Original)
+}
+
+// editTodos calls edit on every selected todo and rewrites the lines of the
+// todos it changed, which are returned.
+func editTodos(s todos.Store, sel selection, edit func(*todos.Todo) (bool, error)) ([]*todos.Todo, error) {
+	if sel.empty() {
+		return nil, errors.New("please, provide the ID of at least one todo or a filter")
+	}
+
+	all, err := loadTodos(s)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := findTodos(all, sel)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := make([]*todos.Todo, 0, len(found))
+	for _, t := range found {
+		ok, err := edit(t)
+		if err != nil {
+			return nil, reported(err)
+		}
+		if ok {
+			changed = append(changed, t)
+		}
+	}
+	if len(changed) == 0 {
+		return changed, nil
+	}
+
+	if err := s.Update(changed...); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// editAndPrint calls edit on every selected todo and prints the ones it
+// changed.
+func editAndPrint(sel selection, edit func(*todos.Todo) (bool, error)) error {
+	edited, err := editTodos(todoStore(), sel, edit)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range edited {
+		todos.PrintLine(t.Line, t.Original)
+	}
+	return nil
+}
+
+// editAction returns the action of a command calling edit on every todo
+// selected by its arguments and flags, and printing the ones it changed.
+func editAction(edit func(*todos.Todo) (bool, error)) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		return editAndPrint(newSelection(c, c.Args().Slice()), edit)
+	}
+}
+
+// rewriteTodo returns the action of a command that rewrites a single todo,
+// given as the first argument, with the rest of the arguments.
+func rewriteTodo(rewrite func(t todos.Todo, text string) (*todos.Todo, error)) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if c.Args().Len() < 2 {
+			return errors.New("please, provide the ID of the todo followed by the text")
+		}
+		text := todos.ExpandDates(strings.Join(c.Args().Tail(), " "), time.Now())
+
+		sel := selection{refs: c.Args().Slice()[:1], byText: c.Bool("text")}
+		return editAndPrint(sel, func(t *todos.Todo) (bool, error) {
+			rewritten, err := rewrite(*t, text)
+			if err != nil {
+				return false, err
+			}
+			*t = *rewritten
+			return true, nil
+		})
+	}
+}
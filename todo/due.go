@@ -0,0 +1,214 @@
+package todo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DueKey is the key of the tag holding a todo's due date, e.g. due:2022-05-01
+const DueKey = "due"
+
+// dateKeys are the keys of the tags whose relative dates are turned into
+// absolute ones by ExpandDates.
+var dateKeys = []string{DueKey, ThresholdKey}
+
+// Due returns the due date of the todo, when its due: tag holds a date.
+func (t Todo) Due() (time.Time, bool) {
+	return t.dateValue(DueKey)
+}
+
+// dateValue returns the date held by the todo's key value tag with the
+// given key.
+func (t Todo) dateValue(key string) (time.Time, bool) {
+	v, ok := t.Value(key)
+	if !ok {
+		return time.Time{}, false
+	}
+	date, err := time.Parse(YYYYMMDD, v)
+	return date, err == nil
+}
+
+// Overdue reports whether the todo is open and was due before the date of now.
+func (t Todo) Overdue(now time.Time) bool {
+	due, ok := t.Due()
+	return ok && !t.Done && due.Before(dateOf(now))
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ResolveDate returns the date value stands for, as seen on the date of now.
+// Besides YYYY-MM-DD dates, values are one of:
+//
+//	today, tomorrow, yesterday
+//	+3d, +2w, +1m, +1y   days, weeks, months or years from today, or before
+//	                     it with a minus sign
+//	+2b                  business days from today, skipping weekends
+//	friday, fri          the first such day after today, also written
+//	                     next friday or next-friday
+func ResolveDate(value string, now time.Time) (time.Time, bool) {
+	today := dateOf(now)
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if date, err := time.Parse(YYYYMMDD, value); err == nil {
+		return date, true
+	}
+
+	switch value {
+	case "today":
+		return today, true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true
+	}
+
+	if day, ok := weekdays[strings.TrimLeft(strings.TrimPrefix(value, "next"), " -")]; ok {
+		days := int(day-today.Weekday()+7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return today.AddDate(0, 0, days), true
+	}
+
+	if n, unit, ok := parseDuration(value); ok && (value[0] == '+' || value[0] == '-') {
+		return addDuration(today, n, unit), true
+	}
+	return time.Time{}, false
+}
+
+// parseDuration parses a number of days, weeks, months, years or business
+// days written as 3d, 2w, 1m, 1y or 2b, optionally signed.
+func parseDuration(value string) (int, byte, bool) {
+	if len(value) < 2 {
+		return 0, 0, false
+	}
+	unit := value[len(value)-1]
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || strings.IndexByte("dwmyb", unit) < 0 {
+		return 0, 0, false
+	}
+	return n, unit, true
+}
+
+// addDuration adds n days, weeks, months, years or business days to date,
+// see parseDuration. Adding months or years to the end of a month stays in
+// the target month, e.g. one month after January 31st is the last day of
+// February.
+func addDuration(date time.Time, n int, unit byte) time.Time {
+	switch unit {
+	case 'd':
+		return date.AddDate(0, 0, n)
+	case 'w':
+		return date.AddDate(0, 0, 7*n)
+	case 'b':
+		return addBusinessDays(date, n)
+	case 'y':
+		n *= 12
+	}
+
+	y, m, d := date.Date()
+	first := time.Date(y, m+time.Month(n), 1, 0, 0, 0, 0, date.Location())
+	if last := first.AddDate(0, 1, -1).Day(); d > last {
+		d = last
+	}
+	return first.AddDate(0, 0, d-1)
+}
+
+// ExpandDates returns line with the relative dates of its due: and t: tags
+// turned into YYYY-MM-DD dates, as seen on the date of now. See ResolveDate
+// for the relative dates understood. Values that aren't dates are kept.
+func ExpandDates(line string, now time.Time) string {
+	var b strings.Builder
+	last := 0
+	for _, token := range scan(line) {
+		if token.tokenType != COLON {
+			continue
+		}
+		key, value, _ := strings.Cut(token.value, ":")
+		if !isDateKey(key) {
+			continue
+		}
+
+		start, end := token.pos+len(key)+1, token.pos+len(token.value)
+		// next friday is written with a space
+		if strings.EqualFold(value, "next") {
+			wordStart := skipWhiteSpace(end, line)
+			wordEnd := moveToWhiteSpace(wordStart, line)
+			if _, ok := weekdays[strings.ToLower(line[wordStart:wordEnd])]; ok {
+				value, end = "next "+line[wordStart:wordEnd], wordEnd
+			}
+		}
+
+		date, ok := ResolveDate(value, now)
+		if !ok || end <= last {
+			continue
+		}
+		b.WriteString(line[last:start])
+		b.WriteString(date.Format(YYYYMMDD))
+		last = end
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
+
+func isDateKey(key string) bool {
+	for _, k := range dateKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// DueFilter returns a Filter selecting the todos due as described by spec,
+// as seen on the date of now. A spec is one of:
+//
+//	overdue              open todos due before today
+//	today                todos due today, or any date ResolveDate knows
+//	this-week            todos due this week, from Monday to Sunday
+//	from..to             todos due between two dates, both included,
+//	                     either of which can be left out
+func DueFilter(spec string, now time.Time) (Filter, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	today := dateOf(now)
+
+	var from, to time.Time
+	switch spec {
+	case "overdue":
+		return func(t *Todo) bool { return t.Overdue(now) }, nil
+	case "this-week":
+		from = today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+		to = from.AddDate(0, 0, 6)
+	default:
+		start, end, isRange := strings.Cut(spec, "..")
+		if !isRange {
+			end = start
+		}
+		var ok bool
+		if len(start) > 0 {
+			if from, ok = ResolveDate(start, now); !ok {
+				return nil, fmt.Errorf("bad due date %q", start)
+			}
+		}
+		if len(end) > 0 {
+			if to, ok = ResolveDate(end, now); !ok {
+				return nil, fmt.Errorf("bad due date %q", end)
+			}
+		}
+		if len(start) == 0 && len(end) == 0 {
+			return nil, fmt.Errorf("bad due date range %q", spec)
+		}
+	}
+
+	return func(t *Todo) bool {
+		due, ok := t.Due()
+		return ok && (from.IsZero() || !due.Before(from)) && (to.IsZero() || !due.After(to))
+	}, nil
+}
@@ -0,0 +1,119 @@
+package todo
+
+import (
+	"testing"
+	"time"
+)
+
+// dueNow is a Wednesday.
+var dueNow = time.Date(2022, 4, 27, 18, 30, 0, 0, time.Local)
+
+func Test_Resolve_Date(t *testing.T) {
+	testcases := []struct{ value, expected string }{
+		{"2022-05-01", "2022-05-01"},
+		{"today", "2022-04-27"},
+		{"Tomorrow", "2022-04-28"},
+		{"yesterday", "2022-04-26"},
+		{"+3d", "2022-04-30"},
+		{"-1w", "2022-04-20"},
+		{"+1m", "2022-05-27"},
+		{"+1y", "2023-04-27"},
+		{"+3b", "2022-05-02"},
+		{"friday", "2022-04-29"},
+		{"wed", "2022-05-04"},
+		{"next friday", "2022-04-29"},
+		{"next-mon", "2022-05-02"},
+	}
+	for _, tc := range testcases {
+		date, ok := ResolveDate(tc.value, dueNow)
+		if !ok || date.Format(YYYYMMDD) != tc.expected {
+			t.Errorf("%q should resolve to %s, but got: %s, %v", tc.value, tc.expected, date.Format(YYYYMMDD), ok)
+		}
+	}
+
+	for _, value := range []string{"now", "3d", "+3x", "someday", "2022-13-01"} {
+		if date, ok := ResolveDate(value, dueNow); ok {
+			t.Errorf("%q shouldn't resolve to a date, but got: %s", value, date.Format(YYYYMMDD))
+		}
+	}
+}
+
+func Test_Add_Months_Stays_In_Month(t *testing.T) {
+	jan31 := time.Date(2022, 1, 31, 0, 0, 0, 0, time.UTC)
+	testcases := []struct {
+		n        int
+		unit     byte
+		expected string
+	}{
+		{1, 'm', "2022-02-28"},
+		{2, 'm', "2022-03-31"},
+		{-2, 'm', "2021-11-30"},
+		{2, 'y', "2024-01-31"},
+	}
+	for _, tc := range testcases {
+		if got := addDuration(jan31, tc.n, tc.unit).Format(YYYYMMDD); got != tc.expected {
+			t.Errorf("Adding %d%c to 2022-01-31 should give %s, but got: %s", tc.n, tc.unit, tc.expected, got)
+		}
+	}
+}
+
+func Test_Expand_Dates(t *testing.T) {
+	testcases := []struct{ input, expected string }{
+		{"Call Mom due:tomorrow", "Call Mom due:2022-04-28"},
+		{"(A) Pay rent due:next friday +House", "(A) Pay rent due:2022-04-29 +House"},
+		{"Post signs due:+3d ends:tomorrow", "Post signs due:2022-04-30 ends:tomorrow"},
+		{"File taxes t:+1w due:+2w", "File taxes t:2022-05-04 due:2022-05-11"},
+		{"Call Mom due:now", "Call Mom due:now"},
+		{"Next friday due:next week", "Next friday due:next week"},
+	}
+	for _, tc := range testcases {
+		if got := ExpandDates(tc.input, dueNow); got != tc.expected {
+			t.Errorf("Expected: %q, but got: %q", tc.expected, got)
+		}
+	}
+}
+
+func Test_Due_Filter(t *testing.T) {
+	todos := loadTodos(t,
+		"Call Mom due:2022-04-20",
+		"x 2022-04-21 Pay rent due:2022-04-20",
+		"Water plants due:2022-04-27",
+		"Call plumber due:2022-05-01",
+		"Fix sink due:2022-05-02",
+		"Buy milk due:now",
+	)
+
+	testcases := []struct {
+		spec     string
+		expected int
+	}{
+		{"overdue", 1},
+		{"today", 1},
+		{"this-week", 2},
+		{"2022-04-20..today", 3},
+		{"tomorrow..", 2},
+		{"..2022-04-20", 2},
+		{"2022-05-02", 1},
+	}
+	for _, tc := range testcases {
+		match, err := DueFilter(tc.spec, dueNow)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := 0
+		for _, todo := range todos {
+			if match(todo) {
+				found++
+			}
+		}
+		if found != tc.expected {
+			t.Errorf("Due filter %q should match %d todos, but matched: %d", tc.spec, tc.expected, found)
+		}
+	}
+
+	for _, spec := range []string{"someday", "..", "today..later"} {
+		if _, err := DueFilter(spec, dueNow); err == nil {
+			t.Errorf("Due filter %q should be rejected.", spec)
+		}
+	}
+}
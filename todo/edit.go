@@ -0,0 +1,148 @@
+package todo
+
+import (
+	"fmt"
+	"time"
+)
+
+// PriorityKey is the key of the tag a complete todo keeps its priority in,
+// e.g. pri:A
+const PriorityKey = "pri"
+
+// dateOf drops the time of day of t, keeping its calendar date.
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// SetValue sets the value of the todo's first key value tag with the given
+// key, adding the tag when there isn't one.
+func (t *Todo) SetValue(key, value string) {
+	for i, tg := range t.Description.Tags {
+		if tg.TagType == KeyValue && tg.Key != nil && *tg.Key == key {
+			t.Description.Tags[i].Value = value
+			return
+		}
+	}
+	t.Description.Tags = append(t.Description.Tags, Tag{TagType: KeyValue, Key: &key, Value: value})
+}
+
+// RemoveValue removes every key value tag with the given key.
+func (t *Todo) RemoveValue(key string) {
+	tags := make([]Tag, 0, len(t.Description.Tags))
+	for _, tg := range t.Description.Tags {
+		if tg.TagType != KeyValue || tg.Key == nil || *tg.Key != key {
+			tags = append(tags, tg)
+		}
+	}
+	t.Description.Tags = tags
+}
+
+// Complete marks the todo as done on the given date, keeping its creation
+// date. Following the todo.txt convention its priority is moved to a pri: tag.
+func (t *Todo) Complete(date time.Time) {
+	completed := dateOf(date)
+	t.Done = true
+	t.CompletionDate = &completed
+
+	if t.Priority != nil {
+		t.SetValue(PriorityKey, *t.Priority)
+		t.Priority = nil
+	}
+}
+
+// Reopen reverses Complete: the todo is marked as not done, its completion
+// date is removed and its priority is restored from its pri: tag.
+func (t *Todo) Reopen() {
+	t.Done = false
+	t.CompletionDate = nil
+
+	if pri, ok := t.Value(PriorityKey); ok && ValidPriority(pri) {
+		t.Priority = &pri
+		t.RemoveValue(PriorityKey)
+	}
+}
+
+// Replace returns the todo parsed from line that takes the place of t.
+// The persistent id of t is kept when line doesn't have one.
+func (t Todo) Replace(line string) (*Todo, error) {
+	replaced, err := Parse(line)
+	if err != nil {
+		return nil, err
+	}
+
+	if id, ok := t.Value(IDKey); ok {
+		if _, ok := replaced.Value(IDKey); !ok {
+			replaced.SetValue(IDKey, id)
+			replaced.Original = replaced.Format()
+		}
+	}
+	replaced.Line = t.Line
+	return replaced, nil
+}
+
+// Append returns the todo re-parsed with text added to the end of its
+// description, so that tags in text become tags of the todo.
+func (t Todo) Append(text string) (*Todo, error) {
+	return t.Replace(t.Format() + " " + text)
+}
+
+// Prepend returns the todo re-parsed with text added to the start of its
+// description, after its completion mark, priority and dates.
+func (t Todo) Prepend(text string) (*Todo, error) {
+	line := t.Format()
+	_, lay, err := parseLine(line)
+	if err != nil {
+		return nil, err
+	}
+	return t.Replace(line[:lay.descStart] + text + " " + line[lay.descStart:])
+}
+
+// ValidPriority reports whether p is a priority, a single capital letter (A-Z).
+func ValidPriority(p string) bool {
+	return len(p) == 1 && p[0] >= 'A' && p[0] <= 'Z'
+}
+
+// SetPriority sets the priority of the todo to p, a capital letter (A-Z).
+func (t *Todo) SetPriority(p string) error {
+	if !ValidPriority(p) {
+		return fmt.Errorf("bad priority value %q, expected a capital letter (A-Z)", p)
+	}
+	t.Priority = &p
+	return nil
+}
+
+// RemovePriority removes the priority of the todo.
+func (t *Todo) RemovePriority() {
+	t.Priority = nil
+}
+
+// Bump raises the priority of the todo by one letter, e.g. from B to A.
+// A todo without priority gets the lowest one, Z. It reports whether the
+// priority changed, which it doesn't when it already is A.
+func (t *Todo) Bump() bool {
+	switch {
+	case t.Priority == nil:
+		t.SetPriority("Z")
+	case *t.Priority == "A":
+		return false
+	default:
+		t.SetPriority(string((*t.Priority)[0] - 1))
+	}
+	return true
+}
+
+// Lower lowers the priority of the todo by one letter, e.g. from A to B.
+// A todo with the lowest priority, Z, loses its priority. It reports
+// whether the priority changed, which it doesn't when there is none.
+func (t *Todo) Lower() bool {
+	switch {
+	case t.Priority == nil:
+		return false
+	case *t.Priority == "Z":
+		t.RemovePriority()
+	default:
+		t.SetPriority(string((*t.Priority)[0] + 1))
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package todo
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Complete_And_Reopen(t *testing.T) {
+	testcases := []struct{ input, done, reopened string }{
+		{"(A) 2022-04-20 Call Mom +Family @phone", "x 2022-05-01 2022-04-20 Call Mom +Family @phone pri:A", "(A) 2022-04-20 Call Mom +Family @phone"},
+		{"Call Mom due:now", "x 2022-05-01 Call Mom due:now", "Call Mom due:now"},
+		{"(B) Call Mom pri:C", "x 2022-05-01 Call Mom pri:B", "(B) Call Mom"},
+	}
+	date := time.Date(2022, 5, 1, 18, 30, 0, 0, time.Local)
+
+	for _, tc := range testcases {
+		todo, err := Parse(tc.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		todo.Complete(date)
+		if got := todo.Format(); got != tc.done {
+			t.Errorf("Completed todo is incorrect. Expected: %q, but got: %q", tc.done, got)
+		}
+		if !todo.Done || todo.CompletionDate == nil || todo.CompletionDate.Format(YYYYMMDD) != "2022-05-01" {
+			t.Errorf("Todo should be done on 2022-05-01, but got: %v", todo)
+		}
+
+		done, err := Parse(tc.done)
+		if err != nil {
+			t.Fatal(err)
+		}
+		done.Reopen()
+		if got := done.Format(); got != tc.reopened {
+			t.Errorf("Reopened todo is incorrect. Expected: %q, but got: %q", tc.reopened, got)
+		}
+	}
+}
+
+func Test_Reopen_Keeps_Bad_Priority_Tag(t *testing.T) {
+	todo, err := Parse("x 2022-05-01 Call Mom pri:high")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	todo.Reopen()
+	expected := "Call Mom pri:high"
+	if got := todo.Format(); got != expected || todo.Priority != nil {
+		t.Errorf("Expected: %q, but got: %q", expected, got)
+	}
+}
+
+func Test_Replace_Append_Prepend(t *testing.T) {
+	todo, err := Parse("(A) 2022-04-20 Call Mom id:3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	todo.Line = 4
+
+	testcases := []struct {
+		name, expected string
+		edit           func() (*Todo, error)
+	}{
+		{"Replace", "(B) Call Dad @phone id:3", func() (*Todo, error) { return todo.Replace("(B) Call Dad @phone") }},
+		{"Replace with id", "Call Dad id:5", func() (*Todo, error) { return todo.Replace("Call Dad id:5") }},
+		{"Append", "(A) 2022-04-20 Call Mom id:3 about +Family @phone", func() (*Todo, error) { return todo.Append("about +Family @phone") }},
+		{"Prepend", "(A) 2022-04-20 @phone Call Mom id:3", func() (*Todo, error) { return todo.Prepend("@phone") }},
+	}
+
+	for _, tc := range testcases {
+		got, err := tc.edit()
+		if err != nil {
+			t.Errorf("%s failed: %v", tc.name, err)
+			continue
+		}
+		if got.Format() != tc.expected || got.Line != 4 {
+			t.Errorf("%s is incorrect. Expected: %q at line 4, but got: %q at line %d", tc.name, tc.expected, got.Format(), got.Line)
+		}
+	}
+
+	appended, _ := todo.Append("about +Family @phone")
+	if getFirstTagOfType(appended.Description.Tags, Project) == nil || getFirstTagOfType(appended.Description.Tags, Context) == nil {
+		t.Errorf("Appended tags should be parsed as tags, but got: %v", appended.Description.Tags)
+	}
+
+	if _, err := todo.Replace("2022-13-01 Call Dad"); err == nil {
+		t.Error("Replacing with a bad todo should return an error.")
+	}
+}
+
+func Test_Bump_And_Lower_Priority(t *testing.T) {
+	testcases := []struct {
+		input, bumped, lowered    string
+		bumpChanged, lowerChanged bool
+	}{
+		{"(B) Call Mom", "(A) Call Mom", "(C) Call Mom", true, true},
+		{"(A) Call Mom", "(A) Call Mom", "(B) Call Mom", false, true},
+		{"(Z) Call Mom", "(Y) Call Mom", "Call Mom", true, true},
+		{"Call Mom", "(Z) Call Mom", "Call Mom", true, false},
+	}
+
+	for _, tc := range testcases {
+		bumped, _ := Parse(tc.input)
+		if changed := bumped.Bump(); changed != tc.bumpChanged || bumped.Format() != tc.bumped {
+			t.Errorf("Bumping %q should give %q (%v), but got: %q (%v)", tc.input, tc.bumped, tc.bumpChanged, bumped.Format(), changed)
+		}
+
+		lowered, _ := Parse(tc.input)
+		if changed := lowered.Lower(); changed != tc.lowerChanged || lowered.Format() != tc.lowered {
+			t.Errorf("Lowering %q should give %q (%v), but got: %q (%v)", tc.input, tc.lowered, tc.lowerChanged, lowered.Format(), changed)
+		}
+	}
+}
+
+func Test_Set_Priority(t *testing.T) {
+	todo, _ := Parse("(A) Call Mom")
+
+	for _, bad := range []string{"", "a", "AB", "1"} {
+		if err := todo.SetPriority(bad); err == nil {
+			t.Errorf("Priority %q should be rejected.", bad)
+		}
+	}
+
+	if err := todo.SetPriority("C"); err != nil || todo.Format() != "(C) Call Mom" {
+		t.Errorf("Expected: \"(C) Call Mom\", but got: %q, %v", todo.Format(), err)
+	}
+	todo.RemovePriority()
+	if todo.Format() != "Call Mom" {
+		t.Errorf("Expected: \"Call Mom\", but got: %q", todo.Format())
+	}
+}
@@ -0,0 +1,137 @@
+package todo
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Exporter writes todos to w in a format, see RegisterExporter.
+type Exporter interface {
+	Export(w io.Writer, todos []*Todo) error
+}
+
+// ExporterFunc is an Exporter written as a function.
+type ExporterFunc func(w io.Writer, todos []*Todo) error
+
+// Export calls f(w, todos).
+func (f ExporterFunc) Export(w io.Writer, todos []*Todo) error {
+	return f(w, todos)
+}
+
+var (
+	exportersMu sync.RWMutex
+	exporters   = make(map[string]Exporter)
+)
+
+// RegisterExporter makes an exporter available by name to Export. It
+// panics when name is already taken or e is nil, as it is meant to be
+// called by init functions.
+func RegisterExporter(name string, e Exporter) {
+	exportersMu.Lock()
+	defer exportersMu.Unlock()
+
+	name = strings.ToLower(name)
+	if e == nil {
+		panic("todo: exporter " + name + " is nil")
+	}
+	if _, ok := exporters[name]; ok {
+		panic("todo: exporter " + name + " is registered twice")
+	}
+	exporters[name] = e
+}
+
+// Exporters returns the sorted names of the registered exporters.
+func Exporters() []string {
+	exportersMu.RLock()
+	defer exportersMu.RUnlock()
+
+	names := make([]string, 0, len(exporters))
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Export writes todos to w with the exporter registered as format. Besides
+// the formats of WriteRecords, the exporters txt, markdown, html and ical
+// are always registered.
+func Export(w io.Writer, format string, todos []*Todo) error {
+	exportersMu.RLock()
+	e, ok := exporters[strings.ToLower(format)]
+	exportersMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown export format %q, expected one of %s", format, strings.Join(Exporters(), ", "))
+	}
+	return e.Export(w, todos)
+}
+
+func init() {
+	for _, format := range OutputFormats {
+		format := format
+		RegisterExporter(format, ExporterFunc(func(w io.Writer, todos []*Todo) error {
+			return WriteRecords(w, format, todos)
+		}))
+	}
+	RegisterExporter("txt", ExporterFunc(exportText))
+	RegisterExporter("markdown", ExporterFunc(exportMarkdown))
+	RegisterExporter("html", ExporterFunc(exportHTML))
+}
+
+// exportText writes todos as todo.txt lines.
+func exportText(w io.Writer, todos []*Todo) error {
+	for _, t := range todos {
+		if _, err := fmt.Fprintln(w, t.Format()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportMarkdown writes todos as a Markdown task list, complete todos being
+// checked.
+func exportMarkdown(w io.Writer, todos []*Todo) error {
+	for _, t := range todos {
+		box, line := "[ ]", t.Format()
+		if t.Done {
+			box, line = "[x]", strings.TrimPrefix(line, "x ")
+		}
+		if _, err := fmt.Fprintf(w, "- %s %s\n", box, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var htmlTemplate = template.Must(template.New("todos").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Todos</title>
+</head>
+<body>
+<ul class="todos">
+{{- range .}}
+<li{{if .Done}} class="done"{{end}}><input type="checkbox" disabled{{if .Done}} checked{{end}}>
+{{- with .Priority}} <span class="priority">({{.}})</span>{{end}} {{.Text}}
+{{- range .Projects}} <span class="project">+{{.}}</span>{{end}}
+{{- range .Contexts}} <span class="context">@{{.}}</span>{{end}}
+{{- with .Due}} <time class="due" datetime="{{.}}">due {{.}}</time>{{end}}</li>
+{{- end}}
+</ul>
+</body>
+</html>
+`))
+
+// exportHTML writes todos as an HTML page listing them.
+func exportHTML(w io.Writer, todos []*Todo) error {
+	records := make([]Record, 0, len(todos))
+	for _, t := range todos {
+		records = append(records, NewRecord(t))
+	}
+	return htmlTemplate.Execute(w, records)
+}
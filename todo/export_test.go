@@ -0,0 +1,76 @@
+package todo
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var exportLines = []string{"(A) Call Mom +Family @phone due:2022-05-01", "x 2022-04-27 Pay <rent> +House"}
+
+func Test_Export(t *testing.T) {
+	todos := loadTodos(t, exportLines...)
+
+	testcases := []struct{ format, expected string }{
+		{"txt", "(A) Call Mom +Family @phone due:2022-05-01\nx 2022-04-27 Pay <rent> +House\n"},
+		{"Markdown", "- [ ] (A) Call Mom +Family @phone due:2022-05-01\n- [x] 2022-04-27 Pay <rent> +House\n"},
+		{"jsonl", `{"line":1,"id":"1"`},
+	}
+	for _, tc := range testcases {
+		var b bytes.Buffer
+		if err := Export(&b, tc.format, todos); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); !strings.HasPrefix(got, tc.expected) {
+			t.Errorf("Exporting as %s should give %q, but got: %q", tc.format, tc.expected, got)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := Export(&b, "html", todos); err != nil {
+		t.Fatal(err)
+	}
+	for _, part := range []string{
+		`<li><input type="checkbox" disabled> <span class="priority">(A)</span> Call Mom <span class="project">+Family</span> <span class="context">@phone</span> <time class="due" datetime="2022-05-01">due 2022-05-01</time></li>`,
+		`<li class="done"><input type="checkbox" disabled checked> Pay &lt;rent&gt; <span class="project">+House</span></li>`,
+	} {
+		if !strings.Contains(b.String(), part) {
+			t.Errorf("HTML export should contain %q, but got: %s", part, b.String())
+		}
+	}
+
+	if err := Export(&b, "pdf", todos); err == nil {
+		t.Error("Exporting as pdf should fail.")
+	}
+}
+
+func Test_Register_Exporter(t *testing.T) {
+	RegisterExporter("count", ExporterFunc(func(w io.Writer, todos []*Todo) error {
+		_, err := io.WriteString(w, strings.Repeat("#", len(todos)))
+		return err
+	}))
+	defer func() {
+		exportersMu.Lock()
+		delete(exporters, "count")
+		exportersMu.Unlock()
+	}()
+
+	var b bytes.Buffer
+	if err := Export(&b, "count", loadTodos(t, exportLines...)); err != nil || b.String() != "##" {
+		t.Errorf("Registered exporter should be used, but got: %q, %v", b.String(), err)
+	}
+
+	expected := []string{"count", "csv", "html", "ical", "json", "jsonl", "markdown", "tsv", "txt"}
+	if got := Exporters(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected exporters: %v, but got: %v", expected, got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Registering an exporter twice should panic.")
+		}
+	}()
+	RegisterExporter("txt", ExporterFunc(exportText))
+}
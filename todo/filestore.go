@@ -0,0 +1,228 @@
+package todo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrConflict is returned when a todo file was changed by someone else
+// since it was loaded, in a way a write would overwrite.
+var ErrConflict = errors.New("todo file changed since it was loaded")
+
+// FileStore is a Store backed by a todo.txt file. A missing file is an
+// empty todo list, created on the first write.
+//
+// Every write takes an advisory lock on the file and atomically replaces it
+// with a temporary file written next to it. Update, Delete and Replace
+// refuse to overwrite lines that changed since the last Load.
+type FileStore struct {
+	Name string
+	// Journal records every change made to the file when it is set
+	Journal *Journal
+
+	// Lines read by the last Load or written since, nil before Load
+	loaded []string
+}
+
+// NewFileStore returns the store of the file called name, todos.txt when
+// name is empty.
+func NewFileStore(name string) *FileStore {
+	return &FileStore{Name: fileOrDefault(name)}
+}
+
+func (s *FileStore) Load() ([]*Todo, error) {
+	lines, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	s.loaded = lines
+	return GetAll(strings.NewReader(strings.Join(lines, "\n")))
+}
+
+func (s *FileStore) Append(todos ...*Todo) error {
+	var n int
+	err := s.modify(func(lines []string) ([]string, error) {
+		n = len(lines)
+		return appendLines(lines, todos), nil
+	})
+	if err != nil {
+		return err
+	}
+	setLines(todos, n)
+	return nil
+}
+
+func (s *FileStore) Update(todos ...*Todo) error {
+	var updated []string
+	err := s.modify(func(lines []string) ([]string, error) {
+		if err := s.checkLines(lines, todos); err != nil {
+			return nil, err
+		}
+		var err error
+		updated, err = updateLines(lines, todos)
+		return updated, err
+	})
+	if err != nil {
+		return err
+	}
+	setOriginals(todos, updated)
+	return nil
+}
+
+func (s *FileStore) Delete(todos ...*Todo) error {
+	return s.modify(func(lines []string) ([]string, error) {
+		if err := s.checkLines(lines, todos); err != nil {
+			return nil, err
+		}
+		return deleteLines(lines, todos)
+	})
+}
+
+func (s *FileStore) Replace(todos ...*Todo) error {
+	err := s.modify(func(lines []string) ([]string, error) {
+		if s.loaded != nil && !equalLines(lines, s.loaded) {
+			return nil, fmt.Errorf("%w: %s", ErrConflict, s.Name)
+		}
+		return appendLines(nil, todos), nil
+	})
+	if err != nil {
+		return err
+	}
+	setLines(todos, 0)
+	return nil
+}
+
+// checkLines returns ErrConflict when a line of todos holds something else
+// in lines, the current content of the file, than when it was loaded.
+func (s *FileStore) checkLines(lines []string, todos []*Todo) error {
+	if s.loaded == nil {
+		return nil
+	}
+	for _, t := range todos {
+		n := t.Line
+		if n < 1 || n > len(s.loaded) {
+			continue
+		}
+		if n > len(lines) {
+			return fmt.Errorf("%w: line %d was removed", ErrConflict, n)
+		}
+		if lines[n-1] != s.loaded[n-1] {
+			return fmt.Errorf("%w: line %d is now %q", ErrConflict, n, lines[n-1])
+		}
+	}
+	return nil
+}
+
+// modify replaces the lines of the file with the ones change returns, while
+// holding its lock, and records the change to the journal of the store.
+func (s *FileStore) modify(change func(lines []string) ([]string, error)) error {
+	before, after, err := s.rewrite(change)
+	if err != nil || s.Journal == nil {
+		return err
+	}
+	// The lock is released, so that undoing a journal entry, which holds
+	// the lock of the journal while writing todo files, can't deadlock
+	if err := s.Journal.record(s.Name, before, after); err != nil {
+		return fmt.Errorf("todos were saved, but not journaled: %w", err)
+	}
+	return nil
+}
+
+// rewrite replaces the lines of the file with the ones change returns, while
+// holding its lock, and returns the lines before and after the change.
+func (s *FileStore) rewrite(change func(lines []string) ([]string, error)) ([]string, []string, error) {
+	unlock, err := lock(s.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+
+	before, err := s.read()
+	if err != nil {
+		return nil, nil, err
+	}
+	after, err := change(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.write(after); err != nil {
+		return nil, nil, err
+	}
+	s.loaded = after
+	return before, after, nil
+}
+
+// read returns the lines of the file, none when it doesn't exist.
+func (s *FileStore) read() ([]string, error) {
+	f, err := os.Open(s.Name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lines, err := GetFromFile(f)
+	if err != nil {
+		return nil, err
+	}
+	// Drop the empty string following the last new line
+	return lines[:len(lines)-1], nil
+}
+
+// write replaces the content of the file with lines.
+func (s *FileStore) write(lines []string) error {
+	return writeFile(s.Name, func(w io.Writer) error {
+		return WriteAll(w, lines)
+	})
+}
+
+// writeFile atomically replaces the content of the file called name with
+// what write writes: it is written and synced to a temporary file next to
+// it, which is then renamed over it. A crash leaves either the old or the
+// new file, never a mix.
+func writeFile(name string, write func(w io.Writer) error) error {
+	// Write through a symbolic link instead of replacing it
+	if target, err := filepath.EvalSymlinks(name); err == nil {
+		name = target
+	}
+
+	mode := fs.FileMode(0644)
+	if fi, err := os.Stat(name); err == nil {
+		mode = fi.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// Only fails once the temporary file replaced the file
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	w := bufio.NewWriter(tmp)
+	if err := write(w); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
@@ -0,0 +1,104 @@
+package todo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func fileStore(t *testing.T, content string) *FileStore {
+	name := filepath.Join(t.TempDir(), "todos.txt")
+	if err := os.WriteFile(name, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return NewFileStore(name)
+}
+
+func Test_File_Store_Writes_Atomically(t *testing.T) {
+	s := fileStore(t, "(A) Call Mom id:1\nPick up dry cleaning id:2")
+	todos, err := s.Load()
+	if err != nil || len(todos) != 2 {
+		t.Fatalf("Expected 2 todos, but got: %v, %v", todos, err)
+	}
+
+	added, _ := Parse("Buy milk")
+	if err := s.Append(added); err != nil || added.Line != 3 {
+		t.Errorf("Appended todo should be at line 3, but got: %d, %v", added.Line, err)
+	}
+	todos[1].Complete(time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC))
+	if err := s.Update(todos[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ := os.ReadFile(s.Name)
+	expected := "(A) Call Mom id:1\nx 2022-05-01 Pick up dry cleaning id:2\nBuy milk\n"
+	if string(content) != expected {
+		t.Errorf("Expected file content %q, but got: %q", expected, content)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(s.Name))
+	if len(entries) != 1 {
+		t.Errorf("Temporary files should be removed, but got: %v", entries)
+	}
+	if fi, _ := os.Stat(s.Name); fi.Mode().Perm() != 0600 {
+		t.Errorf("File mode should be kept, but got: %v", fi.Mode())
+	}
+}
+
+func Test_File_Store_Refuses_Conflicting_Writes(t *testing.T) {
+	s := fileStore(t, "(A) Call Mom id:1\nPick up dry cleaning id:2\n")
+	todos, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Someone else changes the first line and adds a todo
+	changed := "(B) Call Mom id:1\nPick up dry cleaning id:2\nBuy milk\n"
+	if err := os.WriteFile(s.Name, []byte(changed), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	todos[0].RemovePriority()
+	if err := s.Update(todos[0]); !errors.Is(err, ErrConflict) {
+		t.Errorf("Updating a changed line should return ErrConflict, but got: %v", err)
+	}
+	if err := s.Delete(todos[0]); !errors.Is(err, ErrConflict) {
+		t.Errorf("Deleting a changed line should return ErrConflict, but got: %v", err)
+	}
+	if err := s.Replace(todos...); !errors.Is(err, ErrConflict) {
+		t.Errorf("Replacing a changed file should return ErrConflict, but got: %v", err)
+	}
+
+	// Lines nobody else changed can still be written
+	if err := s.Delete(todos[1]); err != nil {
+		t.Fatal(err)
+	}
+	lines, _ := s.read()
+	if expected := []string{"(B) Call Mom id:1", "Buy milk"}; !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Expected lines %q, but got: %q", expected, lines)
+	}
+}
+
+func Test_Lock(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "todos.txt")
+	unlock, err := tryLock(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tryLock(name); !errors.Is(err, errBusy) {
+		t.Errorf("A locked file shouldn't be locked again, but got: %v", err)
+	}
+
+	if err := unlock(); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err = tryLock(name)
+	if err != nil {
+		t.Errorf("An unlocked file should be locked again, but got: %v", err)
+	}
+	unlock()
+}
@@ -0,0 +1,48 @@
+package todo
+
+import "strings"
+
+// Filter reports whether a todo should be selected.
+type Filter func(*Todo) bool
+
+// HasTag reports whether the todo has a tag equal to tag. Values are
+// compared ignoring case.
+func (t Todo) HasTag(tag Tag) bool {
+	for _, tg := range t.Description.Tags {
+		if tg.TagType != tag.TagType || !strings.EqualFold(tg.Value, tag.Value) {
+			continue
+		}
+		if tag.Key == nil || (tg.Key != nil && strings.EqualFold(*tg.Key, *tag.Key)) {
+			return true
+		}
+	}
+	return false
+}
+
+// TagValueFilter returns a Filter selecting the todos having a tag of the
+// given type whose value contains value, ignoring case.
+func TagValueFilter(tag TagType, value string) Filter {
+	value = strings.ToLower(value)
+	return func(t *Todo) bool {
+		for _, tg := range t.Description.Tags {
+			if tg.TagType == tag && strings.Contains(strings.ToLower(tg.Value), value) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// KeyFilter returns a Filter selecting the todos having a key value tag
+// whose key contains key, ignoring case.
+func KeyFilter(key string) Filter {
+	key = strings.ToLower(key)
+	return func(t *Todo) bool {
+		for _, tg := range t.Description.Tags {
+			if tg.TagType == KeyValue && tg.Key != nil && strings.Contains(strings.ToLower(*tg.Key), key) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,185 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Format returns the tag as it is written in a todo.txt line.
+func (t Tag) Format() string {
+	switch t.TagType {
+	case Context:
+		return "@" + t.Value
+	case Project:
+		return "+" + t.Value
+	case KeyValue:
+		if t.Key != nil {
+			return *t.Key + ":" + t.Value
+		}
+	}
+	return t.Value
+}
+
+func (t Tag) equal(other Tag) bool {
+	if t.TagType != other.TagType || t.Value != other.Value {
+		return false
+	}
+	if t.Key == nil || other.Key == nil {
+		return t.Key == other.Key
+	}
+	return *t.Key == *other.Key
+}
+
+// Format returns the todo.txt line of the todo.
+//
+// A todo parsed from a line is formatted by rewriting only the fields that
+// were changed since, so the order of its tokens and its whitespace are
+// kept. Removed tags are taken out of the line, tags that weren't there
+// are appended to it and key value tags that got a new value are updated
+// in place. A changed description text replaces the whole description.
+func (t Todo) Format() string {
+	orig, lay, err := parseLine(t.Original)
+	if len(t.Original) == 0 || err != nil {
+		return t.format()
+	}
+
+	var b strings.Builder
+	if t.Done == orig.Done && equalPriority(t.Priority, orig.Priority) &&
+		equalDate(t.CompletionDate, orig.CompletionDate) && t.CreationDate.Equal(orig.CreationDate) {
+		b.WriteString(t.Original[:lay.descStart])
+	} else {
+		b.WriteString(t.formatHeader())
+	}
+
+	if t.Description.Text != orig.Description.Text {
+		b.WriteString(t.formatDescription())
+		return b.String()
+	}
+
+	// Match the tags of the todo to the tags found in its line
+	used := make([]bool, len(t.Description.Tags))
+	replacements := make([]*Tag, len(orig.Description.Tags))
+	for i, ot := range orig.Description.Tags {
+		for j, tg := range t.Description.Tags {
+			if !used[j] && tg.equal(ot) {
+				used[j] = true
+				tmp := tg
+				replacements[i] = &tmp
+				break
+			}
+		}
+	}
+	for i, ot := range orig.Description.Tags {
+		if replacements[i] != nil || ot.TagType != KeyValue {
+			continue
+		}
+		for j, tg := range t.Description.Tags {
+			if !used[j] && tg.TagType == KeyValue && tg.Key != nil && *tg.Key == *ot.Key {
+				used[j] = true
+				tmp := tg
+				replacements[i] = &tmp
+				break
+			}
+		}
+	}
+
+	desc := t.Original[lay.descStart:]
+	var d strings.Builder
+	last := 0
+	for i, span := range lay.tags {
+		start, end := span[0]-lay.descStart, span[1]-lay.descStart
+		if replacements[i] == nil {
+			// Drop the tag along with the white space leading to it
+			ws := start
+			for ws > last {
+				r, size := utf8.DecodeLastRuneInString(desc[:ws])
+				if !unicode.IsSpace(r) {
+					break
+				}
+				ws -= size
+			}
+			if ws == 0 {
+				end = skipWhiteSpace(end, desc)
+			}
+			d.WriteString(desc[last:ws])
+		} else {
+			d.WriteString(desc[last:start])
+			if replacements[i].equal(orig.Description.Tags[i]) {
+				d.WriteString(desc[start:end])
+			} else {
+				d.WriteString(replacements[i].Format())
+			}
+		}
+		last = end
+	}
+	d.WriteString(desc[last:])
+
+	for j, tg := range t.Description.Tags {
+		if !used[j] {
+			fmt.Fprintf(&d, " %s", tg.Format())
+		}
+	}
+
+	b.WriteString(strings.TrimSpace(d.String()))
+	return b.String()
+}
+
+// format builds the todo.txt line of the todo from its fields alone.
+func (t Todo) format() string {
+	return t.formatHeader() + t.formatDescription()
+}
+
+// formatHeader writes the header of the todo. A done todo with a creation
+// date but no completion date gets its creation date as completion date,
+// the first date after x being read as the completion date.
+func (t Todo) formatHeader() string {
+	var b strings.Builder
+
+	if t.Done {
+		fmt.Fprintf(&b, "x ")
+	}
+
+	if t.Priority != nil {
+		fmt.Fprintf(&b, "(%s) ", *t.Priority)
+	}
+
+	if t.CompletionDate != nil {
+		fmt.Fprintf(&b, "%s ", t.CompletionDate.Format(YYYYMMDD))
+	} else if t.Done && !t.CreationDate.IsZero() {
+		fmt.Fprintf(&b, "%s ", t.CreationDate.Format(YYYYMMDD))
+	}
+	if !t.CreationDate.IsZero() {
+		fmt.Fprintf(&b, "%s ", t.CreationDate.Format(YYYYMMDD))
+	}
+
+	return b.String()
+}
+
+func (t Todo) formatDescription() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s ", t.Description.Text)
+
+	for _, t := range t.Description.Tags {
+		fmt.Fprintf(&b, "%s ", t.Format())
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+func equalPriority(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
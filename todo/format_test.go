@@ -0,0 +1,139 @@
+package todo
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Format_Untouched_Todo_Keeps_Line(t *testing.T) {
+	for _, input := range append(todoLiterals(),
+		"Call +Family about @phone stuff",
+		"x  2011-03-02   2011-03-01 Review  +TodoTxtTouch   @github",
+	) {
+		todo, err := Parse(input)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", input, err)
+		}
+
+		if got := todo.Format(); got != input {
+			t.Errorf("Formatting an untouched todo should return its line. Expected: %q, but got: %q", input, got)
+		}
+	}
+}
+
+func Test_Format_Edited_Todo(t *testing.T) {
+	date := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	priority := "C"
+	key := "due"
+
+	testcases := []struct {
+		name, input, expected string
+		edit                  func(*Todo)
+	}{
+		{
+			"Mark as done",
+			"(A) 2022-04-20  Call Mom +Family  @phone",
+			"x (A) 2022-05-01 2022-04-20 Call Mom +Family  @phone",
+			func(t *Todo) {
+				t.Done = true
+				t.CompletionDate = &date
+			},
+		},
+		{
+			"Change priority",
+			"(A) Call +Family about @phone stuff",
+			"(C) Call +Family about @phone stuff",
+			func(t *Todo) { t.Priority = &priority },
+		},
+		{
+			"Remove inline tag",
+			"Call +Family about @phone stuff",
+			"Call about @phone stuff",
+			func(t *Todo) { t.Description.Tags = t.Description.Tags[1:] },
+		},
+		{
+			"Remove leading tag",
+			"@GroceryStore Eskimo pies",
+			"Eskimo pies",
+			func(t *Todo) { t.Description.Tags = nil },
+		},
+		{
+			"Add tag",
+			"Call +Family about @phone stuff",
+			"Call +Family about @phone stuff +Calls",
+			func(t *Todo) {
+				t.Description.Tags = append(t.Description.Tags, Tag{TagType: Project, Value: "Calls"})
+			},
+		},
+		{
+			"Update key value tag in place",
+			"Post signs due:2018-04-28 +GarageSale",
+			"Post signs due:2022-05-01 +GarageSale",
+			func(t *Todo) { t.Description.Tags[0].Value = "2022-05-01" },
+		},
+		{
+			"Replace key value tag",
+			"Post signs due:2018-04-28 +GarageSale",
+			"Post signs due:2022-05-01 +GarageSale",
+			func(t *Todo) {
+				t.Description.Tags[0] = Tag{TagType: KeyValue, Key: &key, Value: "2022-05-01"}
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			todo, err := Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.edit(todo)
+
+			if got := todo.Format(); got != tc.expected {
+				t.Errorf("Expected: %q, but got: %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func Test_Format_New_Todo(t *testing.T) {
+	priority := "A"
+	key := "due"
+	todo := Todo{
+		Priority:     &priority,
+		CreationDate: time.Date(2022, 4, 20, 0, 0, 0, 0, time.UTC),
+		Description: Description{
+			Text: "Call Mom",
+			Tags: []Tag{{TagType: Project, Value: "Family"}, {TagType: KeyValue, Key: &key, Value: "now"}},
+		},
+	}
+
+	expected := "(A) 2022-04-20 Call Mom +Family due:now"
+	if got := todo.Format(); got != expected {
+		t.Errorf("Expected: %q, but got: %q", expected, got)
+	}
+}
+
+func Test_Format_Done_Todo_Without_Completion_Date_Round_Trip(t *testing.T) {
+	created := time.Date(2022, 4, 20, 0, 0, 0, 0, time.UTC)
+	todo := Todo{Done: true, CreationDate: created, Description: Description{Text: "Call Mom"}}
+
+	line := todo.Format()
+	parsed, err := Parse(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Done || !parsed.CreationDate.Equal(created) {
+		t.Errorf("Creation date should be kept after a round trip, but %q gave: %v", line, parsed.CreationDate)
+	}
+	if parsed.Format() != line {
+		t.Errorf("Formatting again should give the same line. Expected: %q, but got: %q", line, parsed.Format())
+	}
+
+	// a todo edited to be done, keeping the header of its line otherwise
+	edited, _ := Parse("2022-04-20 Call Mom")
+	edited.Done = true
+	if got, expected := edited.Format(), "x 2022-04-20 2022-04-20 Call Mom"; got != expected {
+		t.Errorf("Expected: %q, but got: %q", expected, got)
+	}
+}
@@ -0,0 +1,341 @@
+package todo
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// iCalendar layouts of dates and of UTC times.
+const (
+	icalDate     = "20060102"
+	icalDateTime = "20060102T150405Z"
+)
+
+func init() {
+	RegisterExporter("ical", ExporterFunc(func(w io.Writer, todos []*Todo) error {
+		return exportICal(w, todos, time.Now())
+	}))
+	RegisterImporter("ical", ImporterFunc(importICal))
+}
+
+// exportICal writes todos as the VTODO components of an iCalendar file,
+// stamped with now. The description of a todo, tags included, is its
+// SUMMARY, so that importICal gives back the same todo:
+//
+//	priority          PRIORITY, A to I being 1 to 9 and any lower priority 9,
+//	                  the letter itself being kept in X-TODOTXT-PRIORITY
+//	done              STATUS COMPLETED, NEEDS-ACTION otherwise, the status
+//	                  also being kept in X-TODOTXT-STATUS
+//	completion date   COMPLETED
+//	creation date     CREATED
+//	due: and t:       DUE and DTSTART
+//	tags              CATEGORIES, projects and contexts, e.g. +House,@phone
+func exportICal(w io.Writer, todos []*Todo, now time.Time) error {
+	b := bufio.NewWriter(w)
+	writeICalLine(b, "BEGIN", "VCALENDAR")
+	writeICalLine(b, "VERSION", "2.0")
+	writeICalLine(b, "PRODID", "-//go-do//go-do//EN")
+
+	for _, t := range todos {
+		line := t.Format()
+		_, lay, err := parseLine(line)
+		if err != nil {
+			return err
+		}
+
+		writeICalLine(b, "BEGIN", "VTODO")
+		if id, ok := t.Value(IDKey); ok {
+			writeICalLine(b, "UID", escapeICal(id)+"@go-do")
+		} else {
+			sum := sha256.Sum256([]byte(line))
+			writeICalLine(b, "UID", fmt.Sprintf("%x@go-do", sum[:8]))
+		}
+		writeICalLine(b, "DTSTAMP", now.UTC().Format(icalDateTime))
+		if !t.CreationDate.IsZero() {
+			writeICalLine(b, "CREATED", t.CreationDate.Format(icalDateTime))
+		}
+		writeICalLine(b, "SUMMARY", escapeICal(strings.TrimSpace(line[lay.descStart:])))
+
+		if priority := NewRecord(t).Priority; len(priority) > 0 {
+			n := int(priority[0]-'A') + 1
+			if n > 9 {
+				n = 9
+			}
+			writeICalLine(b, "PRIORITY", strconv.Itoa(n))
+		}
+		if t.Priority != nil {
+			writeICalLine(b, "X-TODOTXT-PRIORITY", *t.Priority)
+		}
+		if due, ok := t.Due(); ok {
+			writeICalLine(b, "DUE;VALUE=DATE", due.Format(icalDate))
+		}
+		if threshold, ok := t.Threshold(); ok {
+			writeICalLine(b, "DTSTART;VALUE=DATE", threshold.Format(icalDate))
+		}
+
+		var categories []string
+		for _, tg := range t.Description.Tags {
+			switch tg.TagType {
+			case Project:
+				categories = append(categories, escapeICal("+"+tg.Value))
+			case Context:
+				categories = append(categories, escapeICal("@"+tg.Value))
+			}
+		}
+		if len(categories) > 0 {
+			writeICalLine(b, "CATEGORIES", strings.Join(categories, ","))
+		}
+
+		status := "NEEDS-ACTION"
+		if t.Done {
+			status = "COMPLETED"
+			if t.CompletionDate != nil {
+				writeICalLine(b, "COMPLETED", t.CompletionDate.Format(icalDateTime))
+			}
+		}
+		writeICalLine(b, "STATUS", status)
+		writeICalLine(b, "X-TODOTXT-STATUS", status)
+		writeICalLine(b, "END", "VTODO")
+	}
+
+	writeICalLine(b, "END", "VCALENDAR")
+	return b.Flush()
+}
+
+// writeICalLine writes a content line, folded to lines of 75 bytes at most.
+func writeICalLine(w *bufio.Writer, name, value string) {
+	line := name + ":" + value
+	for limit := 75; len(line) > limit; limit = 74 {
+		cut := limit
+		// don't split a UTF-8 sequence
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+	w.WriteString(line + "\r\n")
+}
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+// escapeICal escapes a TEXT value.
+func escapeICal(value string) string {
+	return icalEscaper.Replace(value)
+}
+
+var icalUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, " ", `\N`, " ")
+
+// splitICalList splits a list of TEXT values, unescaping them.
+func splitICalList(value string) []string {
+	var values []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			values = append(values, icalUnescaper.Replace(value[start:i]))
+			start = i + 1
+		}
+	}
+	return append(values, icalUnescaper.Replace(value[start:]))
+}
+
+// icalProperty is a content line of a VTODO.
+type icalProperty struct {
+	name, value string
+}
+
+// importICal reads the todos of the VTODO components of an iCalendar file,
+// see exportICal. Components are numbered by the line they start at.
+func importICal(r io.Reader) ([]*Todo, error) {
+	lines, err := unfoldICal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	todos := make([]*Todo, 0)
+	var errs ParseErrors
+	var props []icalProperty
+	start := 0
+	inTodo := false
+	for i, l := range lines {
+		name, value, ok := strings.Cut(l.text, ":")
+		if !ok {
+			continue
+		}
+		// parameters, such as VALUE=DATE, aren't needed
+		name, _, _ = strings.Cut(name, ";")
+		name = strings.ToUpper(name)
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VTODO"):
+			inTodo, start, props = true, lines[i].number, nil
+		case name == "END" && strings.EqualFold(value, "VTODO") && inTodo:
+			inTodo = false
+			t, err := icalTodo(props)
+			if err != nil {
+				perr, ok := err.(*ParseError)
+				if !ok {
+					return nil, err
+				}
+				perr.Line = start
+				errs = append(errs, perr)
+				continue
+			}
+			todos = append(todos, t)
+		case inTodo:
+			props = append(props, icalProperty{name: name, value: value})
+		}
+	}
+
+	if len(errs) > 0 {
+		return todos, errs
+	}
+	return todos, nil
+}
+
+// icalLine is an unfolded content line and the number of the line it
+// starts at.
+type icalLine struct {
+	text   string
+	number int
+}
+
+// unfoldICal returns the content lines of r, joining folded lines.
+func unfoldICal(r io.Reader) ([]icalLine, error) {
+	var lines []icalLine
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		lines = append(lines, icalLine{text: text, number: n})
+	}
+	return lines, scanner.Err()
+}
+
+// icalTodo returns the todo of the properties of a VTODO. A todo whose
+// status was changed in a calendar app is completed or reopened as do and
+// undo would, see Todo.Complete and Todo.Reopen.
+func icalTodo(props []icalProperty) (*Todo, error) {
+	// priority is the one of PRIORITY, todoPriority the one of the todo.txt
+	// line, when it has one
+	var summary, status, priority, todoPriority, todoStatus string
+	var completed, created, due, start time.Time
+	var categories []string
+	for _, p := range props {
+		switch p.name {
+		case "SUMMARY":
+			summary = strings.TrimSpace(icalUnescaper.Replace(p.value))
+		case "STATUS":
+			status = p.value
+		case "X-TODOTXT-STATUS":
+			todoStatus = p.value
+		case "COMPLETED":
+			completed = parseICalDate(p.value)
+		case "CREATED":
+			created = parseICalDate(p.value)
+		case "DUE":
+			due = parseICalDate(p.value)
+		case "DTSTART":
+			start = parseICalDate(p.value)
+		case "PRIORITY":
+			if n, err := strconv.Atoi(p.value); err == nil && n >= 1 && n <= 9 {
+				priority = string(rune('A' + n - 1))
+			}
+		case "X-TODOTXT-PRIORITY":
+			if ValidPriority(p.value) {
+				todoPriority = p.value
+			}
+		case "CATEGORIES":
+			categories = append(categories, splitICalList(p.value)...)
+		}
+	}
+	if len(summary) == 0 {
+		return nil, &ParseError{Column: 1, Reason: "VTODO has no SUMMARY"}
+	}
+
+	// STATUS wins over COMPLETED, which some apps keep on reopened tasks
+	done := strings.EqualFold(status, "COMPLETED") || (len(status) == 0 && !completed.IsZero())
+	// whether the todo.txt line was done, which tasks that don't come from
+	// exportICal tell by a pri: tag, as left by Complete
+	lineDone := strings.EqualFold(todoStatus, "COMPLETED")
+	if len(todoStatus) == 0 {
+		if s, err := Parse(summary); err == nil {
+			pri, ok := s.Value(PriorityKey)
+			lineDone = ok && ValidPriority(pri)
+		}
+	}
+	if len(todoPriority) == 0 && !lineDone {
+		todoPriority = priority
+	}
+
+	header := make([]string, 0, 4)
+	if lineDone {
+		header = append(header, "x")
+	}
+	if len(todoPriority) > 0 {
+		header = append(header, "("+todoPriority+")")
+	}
+	if lineDone && completed.IsZero() {
+		// the first date after x is the completion date, see Todo.Format
+		completed = created
+	}
+	if lineDone && !completed.IsZero() {
+		header = append(header, completed.Format(YYYYMMDD))
+	}
+	if !created.IsZero() {
+		header = append(header, created.Format(YYYYMMDD))
+	}
+	t, err := Parse(strings.Join(append(header, summary), " "))
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case done && !t.Done:
+		if completed.IsZero() {
+			completed = time.Now()
+		}
+		t.Complete(completed)
+	case !done && t.Done:
+		t.Reopen()
+	}
+	if v, _ := t.Value(DueKey); !due.IsZero() && v != due.Format(YYYYMMDD) {
+		t.SetValue(DueKey, due.Format(YYYYMMDD))
+	}
+	if v, _ := t.Value(ThresholdKey); !start.IsZero() && v != start.Format(YYYYMMDD) {
+		t.SetValue(ThresholdKey, start.Format(YYYYMMDD))
+	}
+	for _, c := range categories {
+		tag := Tag{TagType: Project, Value: strings.TrimPrefix(c, "+")}
+		if strings.HasPrefix(c, "@") {
+			tag = Tag{TagType: Context, Value: c[1:]}
+		}
+		if len(tag.Value) > 0 && !strings.ContainsAny(tag.Value, " \t") && !t.HasTag(tag) {
+			t.Description.Tags = append(t.Description.Tags, tag)
+		}
+	}
+	t.Original = t.Format()
+	return t, nil
+}
+
+// parseICalDate returns the date of a DATE or DATE-TIME value, the zero
+// time when it isn't one.
+func parseICalDate(value string) time.Time {
+	if len(value) < len(icalDate) {
+		return time.Time{}
+	}
+	date, _ := time.Parse(icalDate, value[:len(icalDate)])
+	return date
+}
@@ -0,0 +1,179 @@
+package todo
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ICal_Round_Trip(t *testing.T) {
+	lines := append(todoLiterals(),
+		"(C) 2022-04-01 Call plumber; ask for Bob, or Ann +House @phone due:2022-04-28 t:2022-04-25 id:7",
+		"(K) Someday learn the ukulele, see https://example.com/uke?a=1,2",
+		"x 2022-04-27 2022-04-20 Pay rent +House pri:B rec:+1m",
+		"Écrire à Zoë au sujet de la fête d'anniversaire du 12 mai, avec les détails du gâteau et des invités +Famille",
+	)
+	todos := loadTodos(t, lines...)
+
+	var b bytes.Buffer
+	if err := exportICal(&b, todos, dueNow); err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range strings.Split(b.String(), "\r\n") {
+		if len(l) > 75 {
+			t.Errorf("iCalendar lines should be folded to 75 bytes, but got: %q", l)
+		}
+	}
+
+	imported, err := Import(&b, "ical")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != len(lines) {
+		t.Fatalf("Expected %d todos, but got: %d", len(lines), len(imported))
+	}
+	for i, todo := range imported {
+		if todo.Original != lines[i] {
+			t.Errorf("Todo should be the same after a round trip. Expected: %q, but got: %q", lines[i], todo.Original)
+		}
+	}
+}
+
+func Test_ICal_Export(t *testing.T) {
+	todos := loadTodos(t, "(A) 2022-04-20 Call Mom +Family @phone due:2022-05-01 id:3")
+
+	var b bytes.Buffer
+	if err := exportICal(&b, todos, dueNow); err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range []string{
+		"BEGIN:VTODO", "UID:3@go-do", "CREATED:20220420T000000Z", "SUMMARY:Call Mom +Family @phone due:2022-05-01 id:3",
+		"PRIORITY:1", "DUE;VALUE=DATE:20220501", "CATEGORIES:+Family,@phone", "STATUS:NEEDS-ACTION", "X-TODOTXT-STATUS:NEEDS-ACTION",
+		"END:VTODO",
+	} {
+		if !strings.Contains(b.String(), l+"\r\n") {
+			t.Errorf("VTODO should contain %q, but got: %s", l, b.String())
+		}
+	}
+}
+
+func Test_ICal_Import_From_Calendar_Apps(t *testing.T) {
+	input := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VTODO",
+		"UID:abc",
+		"SUMMARY:Buy milk",
+		"PRIORITY:5",
+		"DUE;TZID=Europe/Paris:20220501T170000",
+		"CATEGORIES:Errands,@store",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"SUMMARY:Call Mom due:2022-04-01",
+		"STATUS:COMPLETED",
+		"COMPLETED:20220427T101500Z",
+		"PRIORITY:2",
+		"DUE;VALUE=DATE:20220420",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"SUMMARY:Pay rent +House pri:B",
+		"STATUS:NEEDS-ACTION",
+		"PRIORITY:2",
+		"CATEGORIES:+House",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"SUMMARY:Old thing pri:K",
+		"STATUS:NEEDS-ACTION",
+		"PRIORITY:9",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"DESCRIPTION:No summary",
+		"END:VTODO",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	todos, err := Import(strings.NewReader(input), "ical")
+	expected := []string{
+		"(E) Buy milk due:2022-05-01 +Errands @store",
+		"x 2022-04-27 Call Mom due:2022-04-20 pri:B",
+		"(B) Pay rent +House",
+		"(K) Old thing",
+	}
+	if len(todos) != len(expected) {
+		t.Fatalf("Expected %d todos, but got: %v", len(expected), todos)
+	}
+	for i, todo := range todos {
+		if todo.Original != expected[i] {
+			t.Errorf("Expected: %q, but got: %q", expected[i], todo.Original)
+		}
+	}
+
+	var errs ParseErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Line != 27 {
+		t.Errorf("VTODO without summary should be reported at line 27, but got: %v", err)
+	}
+}
+
+func Test_ICal_Import_Status_Changed_In_Calendar_App(t *testing.T) {
+	todos := loadTodos(t, "(A) Call Mom id:3", "x Old thing pri:K", "x (B) 2022-04-22 2022-04-20 Walk dog")
+
+	var b bytes.Buffer
+	if err := exportICal(&b, todos, dueNow); err != nil {
+		t.Fatal(err)
+	}
+	vtodos := strings.SplitAfter(b.String(), "END:VTODO\r\n")
+	vtodos[0] = strings.Replace(vtodos[0], "STATUS:NEEDS-ACTION", "STATUS:COMPLETED\r\nCOMPLETED:20221001T093000Z", 1)
+	vtodos[1] = strings.Replace(vtodos[1], "STATUS:COMPLETED", "STATUS:NEEDS-ACTION", 1)
+	vtodos[2] = strings.Replace(vtodos[2], "STATUS:COMPLETED", "STATUS:NEEDS-ACTION", 1)
+
+	imported, err := Import(strings.NewReader(strings.Join(vtodos, "")), "ical")
+	if err != nil {
+		t.Fatal(err)
+	}
+	todos[0].Complete(time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC))
+	todos[1].Reopen()
+	todos[2].Reopen()
+	for i, todo := range imported {
+		if expected := todos[i].Format(); todo.Original != expected {
+			t.Errorf("Status changed in a calendar app should be applied as do and undo would. Expected: %q, but got: %q", expected, todo.Original)
+		}
+	}
+	if len(imported) != len(todos) {
+		t.Errorf("Expected %d todos, but got: %d", len(todos), len(imported))
+	}
+}
+
+func Test_ICal_Merge_Changes_Made_In_Calendar_App(t *testing.T) {
+	lines := []string{"(A) Call Mom +Family id:3", "x 2022-04-01 Old thing pri:K id:4", "Water plants id:5"}
+	todos := loadTodos(t, lines...)
+
+	var b bytes.Buffer
+	if err := exportICal(&b, todos, dueNow); err != nil {
+		t.Fatal(err)
+	}
+	vtodos := strings.SplitAfter(b.String(), "END:VTODO\r\n")
+	vtodos[0] = strings.Replace(vtodos[0], "STATUS:NEEDS-ACTION", "STATUS:COMPLETED\r\nCOMPLETED:20221001T093000Z", 1)
+	vtodos[1] = strings.Replace(vtodos[1], "STATUS:COMPLETED", "STATUS:NEEDS-ACTION", 1)
+	imported, err := Import(strings.NewReader(strings.Join(vtodos, "")), "ical")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, added := Merge(todos, imported)
+	if len(updated) != 2 || len(added) != 0 {
+		t.Fatalf("Completed and reopened todos should be updated, the other one skipped, but got: %v, %v", updated, added)
+	}
+	s := NewMemoryStore(lines...)
+	if _, err := s.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Update(updated...); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"x 2022-10-01 Call Mom +Family id:3 pri:A", "(K) Old thing id:4", "Water plants id:5"}
+	if got := s.Lines(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected lines: %q, but got: %q", expected, got)
+	}
+}
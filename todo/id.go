@@ -0,0 +1,83 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IDKey is the key of the tag holding a todo's persistent id, e.g. id:12
+const IDKey = "id"
+
+var ErrNotFound = errors.New("todo not found")
+
+// Value returns the value of the todo's first key value tag with the given key.
+func (t Todo) Value(key string) (string, bool) {
+	for _, tg := range t.Description.Tags {
+		if tg.TagType == KeyValue && tg.Key != nil && *tg.Key == key {
+			return tg.Value, true
+		}
+	}
+	return "", false
+}
+
+// ID returns a reference to the todo that Find accepts: id:<value> when it
+// has a persistent id, otherwise its line number.
+func (t Todo) ID() string {
+	if id, ok := t.Value(IDKey); ok {
+		return IDKey + ":" + id
+	}
+	return strconv.Itoa(t.Line)
+}
+
+// NextID returns the persistent id to give to a new todo, one more than
+// the greatest numeric id in todos.
+func NextID(todos []*Todo) int {
+	max := 0
+	for _, t := range todos {
+		id, ok := t.Value(IDKey)
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(id); err == nil && n > max {
+			max = n
+		}
+	}
+	return max + 1
+}
+
+// Find returns the todo a reference points to. A reference is either the
+// line number of the todo, e.g. 3, or its persistent id, e.g. id:12
+func Find(todos []*Todo, ref string) (*Todo, error) {
+	if id := strings.TrimPrefix(ref, IDKey+":"); id != ref {
+		for _, t := range todos {
+			if v, ok := t.Value(IDKey); ok && v == id {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: no todo with id %q", ErrNotFound, id)
+	}
+
+	line, err := strconv.Atoi(ref)
+	if err != nil {
+		return nil, fmt.Errorf("%q is neither a line number nor an %s:<value> reference", ref, IDKey)
+	}
+	for _, t := range todos {
+		if t.Line == line {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no todo at line %d", ErrNotFound, line)
+}
+
+// FindByText returns the first todo whose line contains text, ignoring case.
+func FindByText(todos []*Todo, text string) (*Todo, error) {
+	text = strings.ToLower(text)
+	for _, t := range todos {
+		if strings.Contains(strings.ToLower(t.Original), text) {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no todo contains %q", ErrNotFound, text)
+}
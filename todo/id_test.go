@@ -0,0 +1,71 @@
+package todo
+
+import (
+	"errors"
+	"testing"
+)
+
+var idLines = []string{
+	"(A) Call Mom +Family id:2",
+	"",
+	"(A) Thank Mom for the meatballs @phone",
+	"x 2011-03-03 Call Mom due:now id:7",
+	"Pick up dry cleaning id:dry",
+}
+
+func Test_Find_By_Line_Number_And_ID(t *testing.T) {
+	todos := loadTodos(t, idLines...)
+
+	testcases := []struct{ ref, expected string }{
+		{"1", "(A) Call Mom +Family id:2"},
+		{"3", "(A) Thank Mom for the meatballs @phone"},
+		{"id:2", "(A) Call Mom +Family id:2"},
+		{"id:7", "x 2011-03-03 Call Mom due:now id:7"},
+		{"id:dry", "Pick up dry cleaning id:dry"},
+	}
+	for _, tc := range testcases {
+		found, err := Find(todos, tc.ref)
+		if err != nil || found.Original != tc.expected {
+			t.Errorf("Reference %q should point to %q, but got: %v, %v", tc.ref, tc.expected, found, err)
+		}
+	}
+
+	for _, ref := range []string{"2", "6", "id:3", "mom"} {
+		if found, err := Find(todos, ref); err == nil {
+			t.Errorf("Reference %q shouldn't point to a todo, but got: %q", ref, found.Original)
+		}
+	}
+
+	if _, err := Find(todos, "id:3"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Missing todo should return ErrNotFound, but got: %v", err)
+	}
+}
+
+func Test_Find_By_Text(t *testing.T) {
+	found, err := FindByText(loadTodos(t, idLines...), "mom")
+	if err != nil || found.Line != 1 {
+		t.Errorf("Should find the first todo containing the text, but got: %v, %v", found, err)
+	}
+}
+
+func Test_ID(t *testing.T) {
+	todos := loadTodos(t, idLines...)
+	if id := todos[0].ID(); id != "id:2" {
+		t.Errorf("ID should be a reference to the id tag. Expected: \"id:2\", but got: %q", id)
+	}
+	if found, err := Find(todos, todos[0].ID()); err != nil || found != todos[0] {
+		t.Errorf("Find should accept the ID, but got: %v, %v", found, err)
+	}
+	if id := todos[1].ID(); id != "3" {
+		t.Errorf("ID should be the line number. Expected: \"3\", but got: %q", id)
+	}
+}
+
+func Test_Next_ID(t *testing.T) {
+	if id := NextID(loadTodos(t, idLines...)); id != 8 {
+		t.Errorf("Expected next id 8, but got: %d", id)
+	}
+	if id := NextID(nil); id != 1 {
+		t.Errorf("Expected next id 1, but got: %d", id)
+	}
+}
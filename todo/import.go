@@ -0,0 +1,327 @@
+package todo
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Importer reads todos from r in a format, see RegisterImporter. Entries
+// that aren't valid todos are skipped and returned as ParseErrors along
+// with the other todos, each one carrying the number of the entry.
+type Importer interface {
+	Import(r io.Reader) ([]*Todo, error)
+}
+
+// ImporterFunc is an Importer written as a function.
+type ImporterFunc func(r io.Reader) ([]*Todo, error)
+
+// Import calls f(r).
+func (f ImporterFunc) Import(r io.Reader) ([]*Todo, error) {
+	return f(r)
+}
+
+var (
+	importersMu sync.RWMutex
+	importers   = make(map[string]Importer)
+)
+
+// RegisterImporter makes an importer available by name to Import. It
+// panics when name is already taken or i is nil, as it is meant to be
+// called by init functions.
+func RegisterImporter(name string, i Importer) {
+	importersMu.Lock()
+	defer importersMu.Unlock()
+
+	name = strings.ToLower(name)
+	if i == nil {
+		panic("todo: importer " + name + " is nil")
+	}
+	if _, ok := importers[name]; ok {
+		panic("todo: importer " + name + " is registered twice")
+	}
+	importers[name] = i
+}
+
+// Importers returns the sorted names of the registered importers.
+func Importers() []string {
+	importersMu.RLock()
+	defer importersMu.RUnlock()
+
+	names := make([]string, 0, len(importers))
+	for name := range importers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Import reads todos from r with the importer registered as format. The
+// importers csv and tsv, CSVImporters mapping columns by their header, and
+// ical are always registered.
+func Import(r io.Reader, format string) ([]*Todo, error) {
+	importersMu.RLock()
+	i, ok := importers[strings.ToLower(format)]
+	importersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown import format %q, expected one of %s", format, strings.Join(Importers(), ", "))
+	}
+	return i.Import(r)
+}
+
+func init() {
+	RegisterImporter("csv", CSVImporter{})
+	RegisterImporter("tsv", CSVImporter{Comma: '\t'})
+}
+
+// Fields of todos CSV columns are mapped to. Columns mapped to any other
+// name become key value tags with that key, the dates of due and t ones
+// being checked and expanded as by ExpandDates.
+const (
+	PriorityColumn    = "priority"
+	DescriptionColumn = "description"
+	ProjectColumn     = "project"
+	ContextColumn     = "context"
+	SkipColumn        = "-"
+)
+
+// CSVImporter reads todos from CSV files whose first row is a header naming
+// the columns. Each column is mapped to a field of the todos by its header,
+// see Columns, and each row is turned into a todo.txt line checked by Parse.
+// Rows are numbered by the line they start at, the header being the first.
+type CSVImporter struct {
+	// Columns maps headers, ignoring case, to the field of the todos their
+	// column holds: priority, description, project, context, - to skip the
+	// column, or the key of a key value tag, e.g. due. Columns that aren't
+	// mapped use their header as field when it names one of the fields or
+	// the due and t dates, and are skipped otherwise.
+	Columns map[string]string
+	// Field delimiter, a comma when it is zero.
+	Comma rune
+}
+
+// ParseColumns parses a comma separated list of header=field mappings, e.g.
+// "Task=description,Deadline=due", see CSVImporter.Columns.
+func ParseColumns(list string) (map[string]string, error) {
+	columns := make(map[string]string)
+	for _, mapping := range strings.Split(list, ",") {
+		header, field, ok := strings.Cut(mapping, "=")
+		header, field = strings.TrimSpace(header), strings.TrimSpace(field)
+		if !ok || len(header) == 0 || len(field) == 0 {
+			return nil, fmt.Errorf("bad column mapping %q, expected header=field", mapping)
+		}
+		columns[strings.ToLower(header)] = strings.ToLower(field)
+	}
+	return columns, nil
+}
+
+// Import reads the todos of the CSV rows of r.
+func (c CSVImporter) Import(r io.Reader) ([]*Todo, error) {
+	cr := csv.NewReader(r)
+	if c.Comma != 0 {
+		cr.Comma = c.Comma
+	}
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return []*Todo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	fields, err := c.fields(header)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	todos := make([]*Todo, 0)
+	var errs ParseErrors
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(strings.TrimSpace(strings.Join(record, ""))) == 0 {
+			continue
+		}
+
+		t, err := rowTodo(fields, record, now)
+		if err != nil {
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				return nil, err
+			}
+			perr.Line, _ = cr.FieldPos(0)
+			errs = append(errs, perr)
+			continue
+		}
+		todos = append(todos, t)
+	}
+
+	if len(errs) > 0 {
+		return todos, errs
+	}
+	return todos, nil
+}
+
+// fields returns the field of each column named by header.
+func (c CSVImporter) fields(header []string) ([]string, error) {
+	fields := make([]string, 0, len(header))
+	hasDescription := false
+	for _, h := range header {
+		name := strings.ToLower(strings.TrimSpace(h))
+		field, ok := c.Columns[name]
+		if !ok {
+			switch name {
+			case PriorityColumn, DescriptionColumn, ProjectColumn, ContextColumn, DueKey, ThresholdKey:
+				field = name
+			default:
+				field = SkipColumn
+			}
+		}
+		if len(field) == 0 {
+			field = SkipColumn
+		}
+		if strings.ContainsAny(field, " \t:") {
+			return nil, fmt.Errorf("column %q can't be a key of key value tags, map it to a field", h)
+		}
+		hasDescription = hasDescription || field == DescriptionColumn
+		fields = append(fields, field)
+	}
+	if !hasDescription {
+		return nil, fmt.Errorf("no column is mapped to %s in header %q", DescriptionColumn, strings.Join(header, ","))
+	}
+	return fields, nil
+}
+
+// Merge matches imported todos with all, the todos of a todo file, e.g. to
+// import again a file exported from it. An imported todo with the persistent
+// id of a todo of all replaces it, getting its Line, unless they are the
+// same (see Same). Other imported todos are added unless the same todo is
+// already there. Imported todos that are neither updated nor added are
+// duplicates.
+func Merge(all, imported []*Todo) (updated, added []*Todo) {
+	byID := make(map[string]*Todo, len(all))
+	for _, t := range all {
+		if id, ok := t.Value(IDKey); ok {
+			byID[id] = t
+		}
+	}
+
+	for _, t := range imported {
+		id, _ := t.Value(IDKey)
+		if existing, ok := byID[id]; ok {
+			delete(byID, id)
+			if !existing.Same(*t) {
+				t.Line = existing.Line
+				updated = append(updated, t)
+			}
+			continue
+		}
+		if !hasSame(all, t) && !hasSame(added, t) {
+			added = append(added, t)
+		}
+	}
+	return updated, added
+}
+
+func hasSame(todos []*Todo, t *Todo) bool {
+	for _, other := range todos {
+		if other.Same(*t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Same reports whether t and other are the same todo, their tags being in
+// any order and their persistent ids left aside, e.g. to skip todos that
+// are imported twice.
+func (t Todo) Same(other Todo) bool {
+	if t.Done != other.Done || !equalPriority(t.Priority, other.Priority) || !equalDate(t.CompletionDate, other.CompletionDate) ||
+		!t.CreationDate.Equal(other.CreationDate) || t.Description.Text != other.Description.Text {
+		return false
+	}
+	return equalLines(t.tagsWithoutID(), other.tagsWithoutID())
+}
+
+// tagsWithoutID returns the sorted tags of the todo but its persistent id.
+func (t Todo) tagsWithoutID() []string {
+	tags := make([]string, 0, len(t.Description.Tags))
+	for _, tg := range t.Description.Tags {
+		if tg.TagType != KeyValue || tg.Key == nil || *tg.Key != IDKey {
+			tags = append(tags, tg.Format())
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// rowTodo returns the todo of a row whose columns hold fields, relative
+// dates being resolved as seen on the date of now.
+func rowTodo(fields, record []string, now time.Time) (*Todo, error) {
+	var priority, description string
+	var tags []string
+	for i, cell := range record {
+		cell = strings.TrimSpace(cell)
+		if i >= len(fields) || len(cell) == 0 {
+			continue
+		}
+
+		switch field := fields[i]; field {
+		case SkipColumn:
+		case PriorityColumn:
+			priority = strings.ToUpper(strings.Trim(cell, "()"))
+			if !ValidPriority(priority) {
+				return nil, &ParseError{Column: 1, Token: cell, Reason: "bad priority, expected a letter (A-Z)", Input: cell}
+			}
+		case DescriptionColumn:
+			description = cell
+		case ProjectColumn, ContextColumn:
+			prefix := "+"
+			if field == ContextColumn {
+				prefix = "@"
+			}
+			for _, name := range strings.FieldsFunc(cell, func(r rune) bool { return r == ',' || r == ' ' }) {
+				tags = append(tags, prefix+strings.TrimLeft(name, "+@"))
+			}
+		default:
+			if isDateKey(field) {
+				date, ok := ResolveDate(cell, now)
+				if !ok {
+					return nil, &ParseError{Column: 1, Token: cell, Reason: fmt.Sprintf("bad date of %s, expected YYYY-MM-DD or e.g. tomorrow", field), Input: cell}
+				}
+				cell = date.Format(YYYYMMDD)
+			}
+			if strings.ContainsAny(cell, " \t") {
+				return nil, &ParseError{Column: 1, Token: cell, Reason: fmt.Sprintf("value of %s has spaces", field), Input: cell}
+			}
+			tags = append(tags, field+":"+cell)
+		}
+	}
+
+	if len(description) == 0 {
+		return nil, &ParseError{Column: 1, Reason: "todo description cannot be empty", Input: strings.Join(record, ",")}
+	}
+	// a description such as "x marks the spot" would be read as a header
+	if d, err := Parse(description); err == nil && (d.Done || d.Priority != nil || !d.CreationDate.IsZero()) {
+		return nil, &ParseError{Column: 1, Token: description, Reason: "description starts like a todo header, e.g. x, (A) or a date", Input: description}
+	}
+
+	parts := make([]string, 0, len(tags)+2)
+	if len(priority) > 0 {
+		parts = append(parts, "("+priority+")")
+	}
+	parts = append(parts, description)
+	return Parse(strings.Join(append(parts, tags...), " "))
+}
@@ -0,0 +1,93 @@
+package todo
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Import_CSV(t *testing.T) {
+	input := `Task,Prio,Project,Context,Deadline,Owner,Notes
+Call Mom,a,Family,phone,2022-05-01,,from the store
+Pay rent,(B),"House, Money",,,ann,monthly
+
+Water plants,,Home,,,,
+,C,,,,,
+Fix sink,high,House,,,,
+Buy milk,,,,,bob smith,
+Sell car,,,,someday,,
+x marks the spot,,,,,,
+2022-05-01 review,B,,,,,
+`
+	columns, err := ParseColumns("task=description, prio=priority,deadline=due, Owner=owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	todos, err := CSVImporter{Columns: columns}.Import(strings.NewReader(input))
+	expected := []string{
+		"(A) Call Mom +Family @phone due:2022-05-01",
+		"(B) Pay rent +House +Money owner:ann",
+		"Water plants +Home",
+	}
+	got := make([]string, 0, len(todos))
+	for _, todo := range todos {
+		got = append(got, todo.Original)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected todos: %q, but got: %q", expected, got)
+	}
+
+	var errs ParseErrors
+	if !errors.As(err, &errs) || len(errs) != 6 {
+		t.Fatalf("6 bad rows should be reported, but got: %v", err)
+	}
+	for i, row := range []int{6, 7, 8, 9, 10, 11} {
+		if errs[i].Line != row {
+			t.Errorf("Bad row %d should be reported, but got: %v", row, errs[i])
+		}
+	}
+}
+
+func Test_Import_By_Format(t *testing.T) {
+	tomorrow, _ := ResolveDate("tomorrow", time.Now())
+	todos, err := Import(strings.NewReader("description\tpriority\tdue\tnotes\nCall Mom\tA\ttomorrow\tabout the party\n"), "TSV")
+	if expected := "(A) Call Mom due:" + tomorrow.Format(YYYYMMDD); err != nil || len(todos) != 1 || todos[0].Original != expected {
+		t.Errorf("TSV should be imported by header, skipping unknown ones, but got: %v, %v", todos, err)
+	}
+
+	if _, err := Import(strings.NewReader("Task,Prio\nCall Mom,A\n"), "csv"); err == nil {
+		t.Error("CSV without a description column should be rejected.")
+	}
+	columns := map[string]string{"due date": "due date"}
+	if _, err := (CSVImporter{Columns: columns}).Import(strings.NewReader("description,Due Date\nCall Mom,2022-05-01\n")); err == nil {
+		t.Error("CSV column mapped to a key with spaces should be rejected.")
+	}
+	if _, err := Import(strings.NewReader(""), "xlsx"); err == nil {
+		t.Error("Importing xlsx should fail.")
+	}
+}
+
+func Test_Same(t *testing.T) {
+	todos := loadTodos(t,
+		"(A) 2022-04-20 Call Mom +Family @phone due:2022-05-01 id:3",
+		"(A) 2022-04-20 Call Mom due:2022-05-01 @phone +Family",
+		"(A) 2022-04-20 Call Mom +Family @phone due:2022-05-02",
+		"x (A) 2022-04-20 Call Mom +Family @phone due:2022-05-01",
+	)
+	for i, expected := range []bool{true, true, false, false} {
+		if got := todos[0].Same(*todos[i]); got != expected {
+			t.Errorf("Same(%q, %q) should be %t", todos[0].Original, todos[i].Original, expected)
+		}
+	}
+}
+
+func Test_Parse_Bad_Columns(t *testing.T) {
+	for _, list := range []string{"", "task", "task=", "=due"} {
+		if _, err := ParseColumns(list); err == nil {
+			t.Errorf("Column mapping %q should be rejected.", list)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package todo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultJournalLimit is the number of entries a journal keeps by default.
+const DefaultJournalLimit = 100
+
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// Change is a change made to a file: starting at Line (1-based), the lines
+// Before were replaced by the lines After.
+type Change struct {
+	File   string   `json:"file"`
+	Line   int      `json:"line"`
+	Before []string `json:"before"`
+	After  []string `json:"after"`
+}
+
+// Entry is a journal entry, the changes made by a command.
+type Entry struct {
+	ID      int       `json:"id"`
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Changes []Change  `json:"changes"`
+}
+
+// history is the content of a journal file.
+type history struct {
+	// Number of entries in effect, the ones after it were undone
+	Position int     `json:"position"`
+	Entries  []Entry `json:"entries"`
+}
+
+// Journal records the changes made to todo files by FileStores, so that
+// they can be undone and redone. Every change recorded by a Journal goes to
+// the same entry, described by Command.
+type Journal struct {
+	// Name of the journal file
+	Name    string
+	Command string
+	// Number of entries kept, the oldest ones are dropped
+	Limit int
+
+	// Entry recorded by the journal, 0 before the first change
+	id int
+}
+
+// NewJournal returns the journal kept in the file called name, recording
+// changes made by command.
+func NewJournal(name, command string) *Journal {
+	return &Journal{Name: name, Command: command, Limit: DefaultJournalLimit}
+}
+
+// diff returns the change of file turning before into after, which covers
+// the lines between their common prefix and suffix. Lines that were only
+// added or removed get a line of context, so that undoing or redoing them
+// can tell whether the file changed in the meantime.
+func diff(file string, before, after []string) (Change, bool) {
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(before)-prefix && suffix < len(after)-prefix &&
+		before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+	if prefix+suffix == len(before) || prefix+suffix == len(after) {
+		switch {
+		case suffix > 0:
+			suffix--
+		case prefix > 0:
+			prefix--
+		}
+	}
+
+	c := Change{
+		File:   file,
+		Line:   prefix + 1,
+		Before: append([]string{}, before[prefix:len(before)-suffix]...),
+		After:  append([]string{}, after[prefix:len(after)-suffix]...),
+	}
+	return c, !equalLines(c.Before, c.After)
+}
+
+// Unified returns the lines of the change as a unified diff does: lines
+// kept by the change start with a space, removed ones with - and added ones
+// with +.
+func (c Change) Unified() []string {
+	// kept[i][j] is the number of lines kept between Before[i:] and After[j:]
+	kept := make([][]int, len(c.Before)+1)
+	for i := range kept {
+		kept[i] = make([]int, len(c.After)+1)
+	}
+	for i := len(c.Before) - 1; i >= 0; i-- {
+		for j := len(c.After) - 1; j >= 0; j-- {
+			switch {
+			case c.Before[i] == c.After[j]:
+				kept[i][j] = kept[i+1][j+1] + 1
+			case kept[i+1][j] >= kept[i][j+1]:
+				kept[i][j] = kept[i+1][j]
+			default:
+				kept[i][j] = kept[i][j+1]
+			}
+		}
+	}
+
+	lines := make([]string, 0, len(c.Before)+len(c.After))
+	i, j := 0, 0
+	for i < len(c.Before) || j < len(c.After) {
+		switch {
+		case i < len(c.Before) && j < len(c.After) && c.Before[i] == c.After[j]:
+			lines = append(lines, "  "+c.Before[i])
+			i, j = i+1, j+1
+		case j == len(c.After) || (i < len(c.Before) && kept[i+1][j] >= kept[i][j+1]):
+			lines = append(lines, "- "+c.Before[i])
+			i++
+		default:
+			lines = append(lines, "+ "+c.After[j])
+			j++
+		}
+	}
+	return lines
+}
+
+// record adds the change of file from before to after to the entry of the
+// journal. Entries that were undone are dropped, they can't be redone
+// anymore.
+func (j *Journal) record(file string, before, after []string) error {
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	c, changed := diff(file, before, after)
+	if !changed {
+		return nil
+	}
+
+	return j.update(func(h *history) error {
+		if n := h.Position; n > 0 && h.Entries[n-1].ID == j.id {
+			h.Entries[n-1].Changes = append(h.Entries[n-1].Changes, c)
+			return nil
+		}
+
+		h.Entries = h.Entries[:h.Position]
+		j.id = 1
+		if n := len(h.Entries); n > 0 {
+			j.id = h.Entries[n-1].ID + 1
+		}
+		h.Entries = append(h.Entries, Entry{ID: j.id, Time: time.Now(), Command: j.Command, Changes: []Change{c}})
+		if j.Limit > 0 && len(h.Entries) > j.Limit {
+			h.Entries = h.Entries[len(h.Entries)-j.Limit:]
+		}
+		h.Position = len(h.Entries)
+		return nil
+	})
+}
+
+// Entries returns the entries of the journal, oldest first, and the number
+// of them in effect. The entries after it were undone.
+func (j *Journal) Entries() ([]Entry, int, error) {
+	h, err := j.read()
+	if err != nil {
+		return nil, 0, err
+	}
+	return h.Entries, h.Position, nil
+}
+
+// Undo reverts the changes of the last entry in effect and returns it.
+// Changes are only reverted when the lines they wrote are unchanged,
+// otherwise ErrConflict is returned.
+func (j *Journal) Undo() (*Entry, error) {
+	var undone Entry
+	err := j.update(func(h *history) error {
+		if h.Position == 0 {
+			return ErrNothingToUndo
+		}
+		undone = h.Entries[h.Position-1]
+
+		reverted := make([]Change, len(undone.Changes))
+		for i, c := range undone.Changes {
+			reverted[len(reverted)-1-i] = Change{File: c.File, Line: c.Line, Before: c.After, After: c.Before}
+		}
+		if err := applyAll(reverted); err != nil {
+			return err
+		}
+		h.Position--
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &undone, nil
+}
+
+// Redo applies again the changes of the first undone entry and returns it.
+// Changes are only applied when the lines they replace are unchanged,
+// otherwise ErrConflict is returned.
+func (j *Journal) Redo() (*Entry, error) {
+	var redone Entry
+	err := j.update(func(h *history) error {
+		if h.Position == len(h.Entries) {
+			return ErrNothingToRedo
+		}
+		redone = h.Entries[h.Position]
+
+		if err := applyAll(redone.Changes); err != nil {
+			return err
+		}
+		h.Position++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &redone, nil
+}
+
+// applyAll applies changes in order. When one of them fails, the ones
+// already applied are reverted.
+func applyAll(changes []Change) error {
+	for i, c := range changes {
+		if err := c.apply(); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				applied := changes[j]
+				Change{File: applied.File, Line: applied.Line, Before: applied.After, After: applied.Before}.apply()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// apply replaces the lines Before of the file, starting at Line, with the
+// lines After. ErrConflict is returned when the file doesn't hold the lines
+// Before there.
+func (c Change) apply() error {
+	s := &FileStore{Name: c.File}
+	_, _, err := s.rewrite(func(lines []string) ([]string, error) {
+		start := c.Line - 1
+		if start < 0 || start+len(c.Before) > len(lines) || !equalLines(lines[start:start+len(c.Before)], c.Before) {
+			return nil, fmt.Errorf("%w: %s at line %d", ErrConflict, c.File, c.Line)
+		}
+
+		changed := make([]string, 0, len(lines)-len(c.Before)+len(c.After))
+		changed = append(changed, lines[:start]...)
+		changed = append(changed, c.After...)
+		return append(changed, lines[start+len(c.Before):]...), nil
+	})
+	return err
+}
+
+// update changes the content of the journal file with change, while
+// holding its lock.
+func (j *Journal) update(change func(h *history) error) error {
+	unlock, err := lock(j.Name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	h, err := j.read()
+	if err != nil {
+		return err
+	}
+	if err := change(h); err != nil {
+		return err
+	}
+	return writeFile(j.Name, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(h)
+	})
+}
+
+// read returns the content of the journal file, an empty history when it
+// doesn't exist or is empty.
+func (j *Journal) read() (*history, error) {
+	h := &history{}
+	data, err := os.ReadFile(j.Name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil || len(data) == 0 {
+		return h, err
+	}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, fmt.Errorf("bad journal file %s: %w", j.Name, err)
+	}
+	if h.Position < 0 || h.Position > len(h.Entries) {
+		h.Position = len(h.Entries)
+	}
+	return h, nil
+}
@@ -0,0 +1,129 @@
+package todo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_Diff(t *testing.T) {
+	testcases := []struct {
+		before, after []string
+		expected      Change
+	}{
+		{[]string{"a", "b", "c"}, []string{"a", "c"}, Change{Line: 2, Before: []string{"b", "c"}, After: []string{"c"}}},
+		{[]string{"a"}, []string{"a", "b"}, Change{Line: 1, Before: []string{"a"}, After: []string{"a", "b"}}},
+		{[]string{}, []string{"a"}, Change{Line: 1, Before: []string{}, After: []string{"a"}}},
+		{[]string{"a", "b", "c", "d"}, []string{"a", "B", "c", "D"}, Change{Line: 2, Before: []string{"b", "c", "d"}, After: []string{"B", "c", "D"}}},
+		{[]string{"a", "a"}, []string{"a"}, Change{Line: 1, Before: []string{"a", "a"}, After: []string{"a"}}},
+	}
+
+	for _, tc := range testcases {
+		got, changed := diff("", tc.before, tc.after)
+		if !changed || !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("Diff of %q and %q should be %v, but got: %v", tc.before, tc.after, tc.expected, got)
+		}
+	}
+
+	if _, changed := diff("", []string{"a"}, []string{"a"}); changed {
+		t.Error("Equal lines shouldn't have a change.")
+	}
+}
+
+func Test_Change_Unified(t *testing.T) {
+	c := Change{Before: []string{"b", "Park idea", "d"}, After: []string{"B", "Park idea", "d", "e"}}
+	expected := []string{"- b", "+ B", "  Park idea", "  d", "+ e"}
+	if got := c.Unified(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %q, but got: %q", expected, got)
+	}
+}
+
+func Test_Journal_Undo_Redo(t *testing.T) {
+	s := fileStore(t, "(A) Call Mom id:1\nPick up dry cleaning id:2\n")
+	dir := filepath.Dir(s.Name)
+	s.Journal = NewJournal(filepath.Join(dir, ".todos.txt.journal"), "delete 1")
+	journal := NewJournal(s.Journal.Name, "")
+
+	todos, _ := s.Load()
+	if err := s.Delete(todos[0]); err != nil {
+		t.Fatal(err)
+	}
+	done := NewFileStore(filepath.Join(dir, "done.txt"))
+	done.Journal = s.Journal
+	if err := done.Append(todos[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if entries, position, _ := journal.Entries(); len(entries) != 1 || position != 1 || len(entries[0].Changes) != 2 {
+		t.Fatalf("Changes of a journal should be recorded to one entry, but got: %v at %d", entries, position)
+	}
+
+	e, err := journal.Undo()
+	if err != nil || e.Command != "delete 1" {
+		t.Fatalf("Undo should revert \"delete 1\", but got: %v, %v", e, err)
+	}
+	lines, _ := s.read()
+	doneLines, _ := done.read()
+	if expected := []string{"(A) Call Mom id:1", "Pick up dry cleaning id:2"}; !reflect.DeepEqual(lines, expected) || len(doneLines) != 0 {
+		t.Errorf("Undo should restore %q and empty the done file, but got: %q, %q", expected, lines, doneLines)
+	}
+	if _, err := journal.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Expected ErrNothingToUndo, but got: %v", err)
+	}
+
+	if _, err := journal.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	lines, _ = s.read()
+	if expected := []string{"Pick up dry cleaning id:2"}; !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Redo should delete the todo again, but got: %q", lines)
+	}
+	if _, err := journal.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("Expected ErrNothingToRedo, but got: %v", err)
+	}
+
+	// A change made since can't be undone over
+	if err := os.WriteFile(s.Name, []byte("Buy milk\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := journal.Undo(); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict, but got: %v", err)
+	}
+	doneLines, _ = done.read()
+	if len(doneLines) != 1 {
+		t.Errorf("A failed undo should leave every file as it was, but got: %q", doneLines)
+	}
+}
+
+func Test_Journal_Is_Bounded(t *testing.T) {
+	s := fileStore(t, "")
+	name := filepath.Join(filepath.Dir(s.Name), ".todos.txt.journal")
+
+	for i := 0; i < 5; i++ {
+		s.Journal = NewJournal(name, "create")
+		s.Journal.Limit = 3
+		todo, _ := Parse("Call Mom")
+		if err := s.Append(todo); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, position, err := s.Journal.Entries()
+	if err != nil || len(entries) != 3 || position != 3 || entries[0].ID != 3 {
+		t.Errorf("Journal should keep the last 3 entries, but got: %v at %d, %v", entries, position, err)
+	}
+
+	// Undone entries are dropped by a new change
+	s.Journal.Undo()
+	s.Journal.Undo()
+	s.Journal = NewJournal(name, "create")
+	todo, _ := Parse("Buy milk")
+	if err := s.Append(todo); err != nil {
+		t.Fatal(err)
+	}
+	if entries, position, _ := s.Journal.Entries(); len(entries) != 2 || position != 2 || entries[1].ID != 4 {
+		t.Errorf("Undone entries should be dropped, but got: %v at %d", entries, position)
+	}
+}
@@ -0,0 +1,36 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrLocked is returned when a todo file stays locked by another process
+// for longer than lockTimeout.
+var ErrLocked = errors.New("todo file is locked by another process")
+
+// errBusy is returned by tryLock when another process holds the lock.
+var errBusy = errors.New("lock is busy")
+
+const (
+	lockTimeout = 5 * time.Second
+	lockRetry   = 50 * time.Millisecond
+)
+
+// lock takes the advisory lock of the file called name, waiting for another
+// process to release it for up to lockTimeout. The returned function
+// releases the lock.
+func lock(name string) (func() error, error) {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		unlock, err := tryLock(name)
+		if !errors.Is(err, errBusy) {
+			return unlock, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: %s", ErrLocked, name)
+		}
+		time.Sleep(lockRetry)
+	}
+}
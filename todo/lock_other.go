@@ -0,0 +1,28 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package todo
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// tryLock creates name.lock, or returns errBusy when it already exists.
+// Unlike an flock, the lock file is left behind when the process holding
+// it crashes and has to be removed by hand.
+func tryLock(name string) (func() error, error) {
+	lockName := name + ".lock"
+	f, err := os.OpenFile(lockName, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		return nil, errBusy
+	}
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+
+	return func() error {
+		return os.Remove(lockName)
+	}, nil
+}
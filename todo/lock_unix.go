@@ -0,0 +1,41 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package todo
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLock takes an flock on the file called name, which is created if
+// needed, or returns errBusy when another process holds it.
+func tryLock(name string) (func() error, error) {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_RDONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, errBusy
+		}
+		return nil, err
+	}
+
+	// Writes rename a new file over name, so the file locked may have been
+	// replaced while the lock was held by someone else
+	locked, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if current, err := os.Stat(name); err != nil || !os.SameFile(locked, current) {
+		f.Close()
+		return nil, errBusy
+	}
+
+	// Closing the file releases the lock
+	return f.Close, nil
+}
@@ -1,12 +1,11 @@
 package todo
 
 import (
-	"errors"
 	"fmt"
-	"log"
-	"regexp"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 const YYYYMMDD = "2006-01-02"
@@ -22,21 +21,70 @@ const (
 	COLON
 	DASH
 	STRING
+	URL
 )
 
 func (t TokenType) String() string {
-	return [...]string{"x", "(", ")", "+", "@", ":", "-", "STRING"}[t]
+	return [...]string{"x", "(", ")", "+", "@", ":", "-", "STRING", "URL"}[t]
 }
 
 type Token struct {
 	tokenType TokenType
 	value     string
+	// Byte offset of the token in the scanned input.
+	pos int
 }
 
 func (t Token) String() string {
 	return fmt.Sprintf("{ tokenType: %s, value: %s }", t.tokenType, t.value)
 }
 
+// ParseError describes a todo line that couldn't be parsed.
+type ParseError struct {
+	// Line number (1-based) of the todo in its file, 0 when unknown.
+	Line int
+	// Byte column (1-based) of the offending token.
+	Column int
+	// The offending token.
+	Token string
+	// Human-readable explanation of the problem.
+	Reason string
+	// The full todo line the error was found in.
+	Input string
+}
+
+func (e *ParseError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d, column %d: %s: %q", e.Line, e.Column, e.Reason, e.Token)
+	}
+	return fmt.Sprintf("column %d: %s: %q", e.Column, e.Reason, e.Token)
+}
+
+// Caret returns the offending line followed by a line with a caret
+// under the column of the bad token.
+func (e *ParseError) Caret() string {
+	end := e.Column - 1
+	if end > len(e.Input) {
+		end = len(e.Input)
+	}
+	if end < 0 {
+		end = 0
+	}
+	pad := strings.Repeat(" ", utf8.RuneCountInString(e.Input[:end]))
+	return e.Input + "\n" + pad + "^"
+}
+
+// ParseErrors collects the problems found while parsing many todo lines.
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
 type TagType int8
 
 const (
@@ -65,23 +113,72 @@ func (t Tag) String() string {
 	return fmt.Sprintf("{ tagType: %v, value: %s, key: %v }", t.TagType, t.Value, t.Key)
 }
 
+// Segment is either a run of plain text or a single tag of a description.
+type Segment struct {
+	// The segment as it is written in the todo line.
+	Text string
+	// The tag, when the segment is one.
+	Tag *Tag
+}
+
 type Description struct {
-	// Text content for the description.
+	// Text content for the description, without its tags.
 	Text string
 	// List of description's Tags.
 	Tags []Tag
+	// Text and tags of the description in the order they are written.
+	Segments []Segment
 }
 
 func (d Description) String() string {
 	return fmt.Sprintf("{ text: %s, tags: %v }", d.Text, d.Tags)
 }
 
+// textSegments splits a description into its text and tag segments, given
+// the byte ranges of its tags.
+func textSegments(desc string, tags []Tag, spans [][2]int) []Segment {
+	segments := make([]Segment, 0, 2*len(tags)+1)
+	addText := func(text string) {
+		if text = strings.TrimSpace(text); len(text) > 0 {
+			segments = append(segments, Segment{Text: text})
+		}
+	}
+
+	last := 0
+	for i, span := range spans {
+		addText(desc[last:span[0]])
+		segments = append(segments, Segment{Text: desc[span[0]:span[1]], Tag: &tags[i]})
+		last = span[1]
+	}
+	addText(desc[last:])
+
+	return segments
+}
+
+// plainText joins the text segments of a description.
+func plainText(segments []Segment) string {
+	var b strings.Builder
+	for _, s := range segments {
+		if s.Tag != nil {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(s.Text)
+	}
+	return b.String()
+}
+
 type Todo struct {
 	// Mandatory: Description + tags section of the todo.
 	Description Description
 
 	// Auto-generated: the original todo.txt string of this todo
 	Original string
+	// Auto-generated: Line number (1-based) of the todo in its file,
+	// 0 when it wasn't read from a file.
+	Line int
 
 	// Optional: Todo is complete
 	Done bool
@@ -89,10 +186,14 @@ type Todo struct {
 	// enclosed in parentheses, e.g., (A)
 	Priority *string
 
-	// Auto-generated: Date the todo was created at (YYYY-MM-DD)
+	// Auto-generated: URLs found in the description, e.g. https://go.dev
+	Links []string
+
+	// Optional: Date the todo was created at (YYYY-MM-DD).
+	// It is the zero time when the todo has no creation date.
 	CreationDate time.Time
 	// Optional: Date the todo was completed (YYYY-MM-DD).
-	// Its existence is dependent on creationDate.
+	// Only complete todos have one, written before their creation date.
 	CompletionDate *time.Time
 }
 
@@ -114,66 +215,32 @@ func (t Todo) String() string {
 	return b.String()
 }
 
-func (t Todo) Format() string {
-	var b strings.Builder
-
-	if t.Done {
-		fmt.Fprintf(&b, "x ")
-	}
-
-	if t.Priority != nil {
-		fmt.Fprintf(&b, "(%s) ", *t.Priority)
+// runeAt decodes the rune starting at byte offset current of input.
+func runeAt(current int, input string) (rune, int) {
+	if c := input[current]; c < utf8.RuneSelf {
+		return rune(c), 1
 	}
-
-	if t.CompletionDate != nil {
-		fmt.Fprintf(&b, "%s ", t.CompletionDate.Format(YYYYMMDD))
-	}
-	fmt.Fprintf(&b, "%s ", t.CreationDate.Format(YYYYMMDD))
-
-	fmt.Fprintf(&b, "%s ", t.Description.Text)
-
-	for _, t := range t.Description.Tags {
-		switch t.TagType {
-		case Context:
-			fmt.Fprintf(&b, "@%s ", t.Value)
-		case Project:
-			fmt.Fprintf(&b, "+%s ", t.Value)
-		case KeyValue:
-			fmt.Fprintf(&b, "%s:%s ", *t.Key, t.Value)
-		}
-	}
-
-	return strings.TrimRight(b.String(), " ")
+	return utf8.DecodeRuneInString(input[current:])
 }
 
 func moveToWhiteSpace(start int, input string) int {
 	i := start
-	for ; !isAtEnd(i, input); i++ {
-		if isWhiteSpace(i, input) {
+	for !isAtEnd(i, input) {
+		r, size := runeAt(i, input)
+		if unicode.IsSpace(r) {
 			break
 		}
+		i += size
 	}
 	return i
 }
 
-func projectLiteral(curr int, input string) (int, Token) {
-	var content string
-	start, i := curr, curr
-	i = moveToWhiteSpace(i, input)
-
-	content += input[start+1 : i]
-	log.Printf("Project Literal==== Content: %s, current: %v\n", content, i)
-	return i - curr, Token{tokenType: PLUS, value: content}
+func projectLiteral(start, end int, input string) Token {
+	return Token{tokenType: PLUS, value: input[start+1 : end], pos: start}
 }
 
-func contextLiteral(curr int, input string) (int, Token) {
-	var content string
-	start, i := curr, curr
-	i = moveToWhiteSpace(i, input)
-
-	content += input[start+1 : i]
-	log.Printf("Context Literal==== Content: %s, current: %v\n", content, curr)
-	return i - curr, Token{tokenType: AT, value: content}
+func contextLiteral(start, end int, input string) Token {
+	return Token{tokenType: AT, value: input[start+1 : end], pos: start}
 }
 
 func isAtEnd(current int, input string) bool {
@@ -181,115 +248,180 @@ func isAtEnd(current int, input string) bool {
 }
 
 func isWhiteSpace(current int, input string) bool {
-	matched, err := regexp.MatchString(`(\s+)`, string(input[current]))
-	if err != nil {
-		log.Printf("regex for string %s with index %v\n", input, current)
-	}
-	return matched
+	r, _ := runeAt(current, input)
+	return unicode.IsSpace(r)
 }
 
 func isCapitalLetter(current int, input string) bool {
-	matched, err := regexp.MatchString(`[A-Z]{1}`, string(input[current]))
-	if err != nil {
-		log.Printf("regex for string %s with index %v\n", input, current)
+	return input[current] >= 'A' && input[current] <= 'Z'
+}
+
+func keyValueLiteral(start, end int, input string) Token {
+	return Token{tokenType: COLON, value: input[start:end], pos: start}
+}
+
+func urlLiteral(start, end int, input string) Token {
+	return Token{tokenType: URL, value: strings.TrimRight(input[start:end], ".,;!?)"), pos: start}
+}
+
+// isURL reports whether word starts with a URL scheme, e.g. https:// or mailto:
+func isURL(word string) bool {
+	colon := strings.IndexByte(word, ':')
+	if colon < 1 {
+		return false
+	}
+	for i := 0; i < colon; i++ {
+		c := word[i]
+		isLetter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !isLetter && (i == 0 || ((c < '0' || c > '9') && c != '+' && c != '-' && c != '.')) {
+			return false
+		}
+	}
+	return strings.HasPrefix(word[colon:], "://") || strings.EqualFold(word[:colon], "mailto")
+}
+
+// isKeyValue reports whether a word containing a colon is a key value tag:
+// its key is a plain identifier, not only digits as in times (10:30) or
+// ratios (3:1), and its value isn't empty nor a path as after a scheme or
+// a drive letter (C:\Users). Words such as "Note:" aren't tags.
+func isKeyValue(word string) bool {
+	colon := strings.IndexByte(word, ':')
+	if colon < 1 || colon == len(word)-1 {
+		return false
+	}
+	key, value := word[:colon], word[colon+1:]
+	if value[0] == '\\' || value[0] == '/' {
+		return false
 	}
-	return matched
+	for i, r := range key {
+		isLetter := unicode.IsLetter(r) || r == '_'
+		if !isLetter && (i == 0 || (!unicode.IsDigit(r) && r != '-')) {
+			return false
+		}
+	}
+	return true
 }
 
-func keyValueLiteral(curr int, input string) (int, Token) {
-	colonPos := curr
+func wordEnd(curr int, input string) int {
+	return moveToWhiteSpace(curr, input)
+}
 
-	keyBegin := colonPos - 1
-	for keyBegin >= 0 && !isWhiteSpace(keyBegin, input) {
-		keyBegin--
+func skipWhiteSpace(curr int, input string) int {
+	for !isAtEnd(curr, input) {
+		r, size := runeAt(curr, input)
+		if !unicode.IsSpace(r) {
+			break
+		}
+		curr += size
 	}
-	keyBegin++
+	return curr
+}
 
-	i := colonPos + 1
-	i = moveToWhiteSpace(i, input)
-	value := input[colonPos:i]
-	log.Printf("KV Token: { key: %s, value: %s }\n", input[keyBegin:colonPos], value)
+// looksLikeDate reports whether a word is written as a YYYY-MM-DD date,
+// even one that doesn't exist such as 2015-02-30. Other words made of
+// digits and dashes, e.g. 2022-05 or 1-2-3, are description text.
+func looksLikeDate(word string) bool {
+	if len(word) != len(YYYYMMDD) {
+		return false
+	}
+	for i := 0; i < len(word); i++ {
+		if (word[i] == '-') != (YYYYMMDD[i] == '-') || (word[i] != '-' && (word[i] < '0' || word[i] > '9')) {
+			return false
+		}
+	}
+	return true
+}
 
-	return len(value), Token{tokenType: COLON, value: input[keyBegin:colonPos] + value}
+// isPriority reports whether the word at curr is a priority, a capital
+// letter in parentheses such as (A). Any other word in parentheses, e.g.
+// (optional), is part of the description.
+func isPriority(curr int, input string) bool {
+	return wordEnd(curr, input)-curr == 3 && input[curr] == '(' && input[curr+2] == ')' && isCapitalLetter(curr+1, input)
 }
 
-func handlePriority(curr int, input string) (int, *Token, error) {
-	if string(input[curr+2]) != RIGHT_PAREN.String() || !isCapitalLetter(curr+1, input) {
-		return 0, nil, errors.New("bad priority value")
+func handleDate(curr int, input string) (int, time.Time, error) {
+	end := wordEnd(curr, input)
+	dateValue := input[curr:end]
+	date, err := time.Parse(YYYYMMDD, dateValue)
+	if err != nil {
+		return 0, date, &ParseError{
+			Column: curr + 1,
+			Token:  dateValue,
+			Reason: "bad format for date, expected YYYY-MM-DD",
+		}
 	}
-	value := string(input[curr+1])
-	return 3, &Token{tokenType: LEFT_PAREN, value: value}, nil
+
+	return end - curr, date, nil
 }
 
-func handleDate(curr int, input string) (int, *Token, error) {
-	pos := strings.Index(input, DASH.String())
-	log.Printf("Pos starting value: %d\n", pos)
-	// Parse the year backwards
-	yearStart := pos - 4
-	dateValue := ""
-	if yearStart < 0 {
-		return 0, nil, errors.New("couldn't parse date")
+// parseHeader reads the completion mark, priority and dates a todo may
+// start with and returns the byte offset its description starts at.
+//
+// An incomplete todo may have a creation date. A complete todo may have a
+// completion date, which can be followed by a creation date. Any other date
+// is part of the description.
+func parseHeader(todo *Todo, input string) (int, error) {
+	curr := 0
+
+	// Handle todo completion
+	if len(input) > 1 && input[0] == 'x' && isWhiteSpace(1, input) {
+		todo.Done = true
+		curr = skipWhiteSpace(1, input)
 	}
 
-	year, month, day := "", "", ""
-	year = input[yearStart:pos]
-	pos++
-	month = input[pos : pos+2]
-	pos += 3
-	day = input[pos : pos+2]
-	pos += 3
+	if isPriority(curr, input) {
+		priority := input[curr+1 : curr+2]
+		todo.Priority = &priority
+		curr = skipWhiteSpace(curr+3, input)
+	}
 
-	dateValue = year + "-" + month + "-" + day
-	isValid, err := regexp.MatchString(`^\d{4}-\d{2}-\d{2}$`, dateValue)
-	log.Printf("Slice from new current value: %s, completion date: %s\n", input[curr:], dateValue)
+	maxDates := 1
+	if todo.Done {
+		maxDates = 2
+	}
+	dates := make([]time.Time, 0, maxDates)
+	for len(dates) < maxDates && looksLikeDate(input[curr:wordEnd(curr, input)]) {
+		offset, date, err := handleDate(curr, input)
+		if err != nil {
+			return 0, err
+		}
+		dates = append(dates, date)
+		curr = skipWhiteSpace(curr+offset, input)
+	}
 
-	if isValid && err != nil {
-		return 0, nil, errors.New("bad format for date")
+	switch {
+	case todo.Done && len(dates) > 0:
+		todo.CompletionDate = &dates[0]
+		if len(dates) > 1 {
+			todo.CreationDate = dates[1]
+		}
+	case len(dates) > 0:
+		todo.CreationDate = dates[0]
 	}
 
-	// subtract 4 for year
-	return len(dateValue) - 5, &Token{tokenType: DASH, value: dateValue}, nil
+	return curr, nil
 }
 
+// scan splits a description into words and returns a token for every
+// word that is a tag. Tags start at the beginning of a word.
 func scan(input string) []Token {
-	curr := 0 // current char
-	tokens := []Token{{tokenType: STRING, value: input}}
+	tokens := make([]Token, 0)
 
+	curr := skipWhiteSpace(0, input)
 	for !isAtEnd(curr, input) {
-		char := string(input[curr])
-		switch char {
-		case DONE_CHAR.String():
-			tokens = append(tokens, Token{tokenType: DONE_CHAR})
-		case LEFT_PAREN.String():
-			offset, token, err := handlePriority(curr, input)
-			if err != nil {
-				panic(err)
-			}
-			tokens = append(tokens, *token)
-			curr += offset
-		case DASH.String():
-			offset, token, err := handleDate(curr, input)
-			if err != nil {
-				panic(err)
-			}
-			tokens = append(tokens, *token)
-			curr += offset
-		case PLUS.String():
-			offset, token := projectLiteral(curr, input)
-			tokens = append(tokens, token)
-			curr += offset
-		case AT.String():
-			offset, token := contextLiteral(curr, input)
-			tokens = append(tokens, token)
-			curr += offset
-		case COLON.String():
-			offset, token := keyValueLiteral(curr, input)
-			tokens = append(tokens, token)
-			curr += offset
-		default:
+		end := wordEnd(curr, input)
+		switch {
+		case end-curr > 1 && input[curr] == '+':
+			tokens = append(tokens, projectLiteral(curr, end, input))
+		case end-curr > 1 && input[curr] == '@':
+			tokens = append(tokens, contextLiteral(curr, end, input))
+		case isURL(input[curr:end]):
+			tokens = append(tokens, urlLiteral(curr, end, input))
+		case isKeyValue(input[curr:end]):
+			tokens = append(tokens, keyValueLiteral(curr, end, input))
 		}
 
-		curr++
+		curr = skipWhiteSpace(end, input)
 	}
 	return tokens
 }
@@ -297,110 +429,99 @@ func scan(input string) []Token {
 func handleKeyValueTag(token Token) (*Tag, error) {
 	colonPos := strings.Index(token.value, ":")
 	if colonPos < 0 {
-		log.Printf("String doesn't contain colon. String: %s", token.value)
-		return nil, errors.New("colon character not found in string")
+		return nil, &ParseError{Column: token.pos + 1, Token: token.value, Reason: "colon character not found in key value tag"}
 	}
 	key := token.value[0:colonPos]
 	value := token.value[colonPos+1:]
 	if value == "" || value == ":" {
-		return nil, errors.New("value cannot be empty")
+		return nil, &ParseError{Column: token.pos + 1, Token: token.value, Reason: "key value tag value cannot be empty"}
 	}
 
 	kvTag := Tag{TagType: KeyValue, Key: &key, Value: value}
-	log.Printf("kvTag: %v\n", kvTag)
 	return &kvTag, nil
 }
 
-func stripRight(desc string, val string, input string) string {
-	var b strings.Builder
-	pos := strings.Index(desc, val)
-	if pos >= 0 {
-		before := desc[:pos-1]
-		b.WriteString(before)
-	} else {
-		b.WriteString(desc)
+// withInput points a parse error found in a slice of line, starting at
+// byte offset, back to the full line.
+func withInput(err error, line string, offset int) error {
+	if perr, ok := err.(*ParseError); ok {
+		perr.Column += offset
+		perr.Input = line
 	}
-	return strings.TrimSpace(b.String())
+	return err
 }
 
-func stripLeft(desc string, val string, input string) string {
-	var b strings.Builder
-	pos := strings.Index(desc, val)
-	if pos >= 0 {
-		afterLen := len(val) + 1
-		if afterLen < len(input) {
-			after := input[afterLen:]
-			b.WriteString(after)
-		}
-	} else {
-		b.WriteString(desc)
-	}
-	return strings.TrimSpace(b.String())
+// layout remembers where the parts of a parsed line were found in it,
+// so that Format can rewrite only the parts that changed.
+type layout struct {
+	// Byte offset the description starts at.
+	descStart int
+	// Byte ranges of the description's tags, in the same order.
+	tags [][2]int
 }
 
+// Parse reads a single todo.txt line. Malformed lines are reported
+// with a *ParseError.
 func Parse(input string) (*Todo, error) {
-	log.Printf("Got: %s\n", input)
-	input = strings.Trim(input, " ")
+	todo, _, err := parseLine(input)
+	return todo, err
+}
+
+func parseLine(input string) (*Todo, *layout, error) {
+	line := input
+	input = strings.TrimLeft(input, " ")
+	offset := len(line) - len(input)
+	input = strings.TrimRight(input, " ")
+
+	if len(input) == 0 {
+		return nil, nil, &ParseError{Column: 1, Reason: "todo cannot be empty", Input: line}
+	}
 
 	todo := &Todo{
-		Done:         false,
-		CreationDate: time.Now().UTC(),
-		Original:     input,
+		Done:     false,
+		Original: input,
 	}
 
-	// Handle todo completion
-	if string(input[0]) == DONE_CHAR.String() && len(input) > 1 && string(input[1]) == " " {
-		todo.Done = true
-		input = input[2:]
+	descStart, err := parseHeader(todo, input)
+	if err != nil {
+		return nil, nil, withInput(err, line, offset)
 	}
+	if isAtEnd(descStart, input) {
+		return nil, nil, withInput(&ParseError{Column: descStart + 1, Reason: "todo description cannot be empty"}, line, offset)
+	}
+	lay := &layout{descStart: descStart}
+	input = input[descStart:]
+	offset += descStart
+
 	tokens := scan(input)
 
+	todo.Description.Tags = make([]Tag, 0, len(tokens))
+	spans := make([][2]int, 0, len(tokens))
 	for _, token := range tokens {
 		switch token.tokenType {
-		case LEFT_PAREN:
-			tmp := token.value
-			todo.Priority = &tmp
-		case STRING:
-			todo.Description.Text = token.value
 		case PLUS:
 			todo.Description.Tags = append(todo.Description.Tags, Tag{TagType: Project, Value: token.value})
+			spans = append(spans, [2]int{token.pos, token.pos + len(token.value) + 1})
 		case AT:
 			todo.Description.Tags = append(todo.Description.Tags, Tag{TagType: Context, Value: token.value})
+			spans = append(spans, [2]int{token.pos, token.pos + len(token.value) + 1})
 		case COLON:
 			kvTag, err := handleKeyValueTag(token)
 			if err != nil {
-				return nil, err
+				return nil, nil, withInput(err, line, offset)
 			}
 			todo.Description.Tags = append(todo.Description.Tags, *kvTag)
-
-			// We have to clean up the description text since
-			// it's picking up on the key of the first key value tag
-			colonPos := strings.Index(token.value, COLON.String())
-			keyToRemove := token.value[0:colonPos]
-
-			keyStartPos := strings.Index(todo.Description.Text, keyToRemove)
-			if keyStartPos >= 0 {
-				todo.Description.Text = todo.Description.Text[0:keyStartPos]
-			}
-		case DASH:
-			date, err := time.Parse(YYYYMMDD, token.value)
-			log.Printf("date: %s\n", date.Format(YYYYMMDD))
-			if err != nil {
-				log.Printf("bad date: %s\n", token.value)
-				return nil, errors.New("could not parse completion date")
-			}
-			todo.Description.Text = stripLeft(todo.Description.Text, token.value, input)
-			todo.CompletionDate = &date
+			spans = append(spans, [2]int{token.pos, token.pos + len(token.value)})
+		case URL:
+			todo.Links = append(todo.Links, token.value)
 		}
 	}
 
-	if todo.Priority != nil {
-		todo.Description.Text = todo.Description.Text[4:]
-	}
-
-	for _, t := range todo.Description.Tags {
-		todo.Description.Text = stripRight(todo.Description.Text, t.Value, input)
+	todo.Description.Segments = textSegments(input, todo.Description.Tags, spans)
+	todo.Description.Text = plainText(todo.Description.Segments)
+	for _, span := range spans {
+		lay.tags = append(lay.tags, [2]int{descStart + span[0], descStart + span[1]})
 	}
 
-	return todo, nil
+	return todo, lay, nil
 }
@@ -193,10 +193,10 @@ func Test_Parse_Description_With_Key_Value_Tag(t *testing.T) {
 
 func Test_Parse_Key_Value_Tag_Empty_Value(t *testing.T) {
 	input := "call customer due:"
-	_, err := Parse(input)
+	todo, err := Parse(input)
 
-	if err == nil {
-		t.Error("Trying to pass a key value tag without suppling a value should return an error.")
+	if err != nil || len(todo.Description.Tags) != 0 || todo.Description.Text != input {
+		t.Errorf("A key without a value should be description text, but got: %v, %v", todo, err)
 	}
 }
 
@@ -257,6 +257,37 @@ func Test_Parse_Description_With_Multiple_Of_Each_Tag(t *testing.T) {
 	}
 }
 
+func Test_Parse_Tags_Inside_Description(t *testing.T) {
+	input := "Email @work the +Budget draft to  Anna due:2022-05-01"
+	todo, err := Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedText := "Email the draft to  Anna"
+	if todo.Description.Text != expectedText {
+		t.Errorf("Description text is incorrect. Expected: %q, but got: %q", expectedText, todo.Description.Text)
+	}
+
+	expected := []Segment{
+		{Text: "Email"},
+		{Text: "@work", Tag: &Tag{TagType: Context, Value: "work"}},
+		{Text: "the"},
+		{Text: "+Budget", Tag: &Tag{TagType: Project, Value: "Budget"}},
+		{Text: "draft to  Anna"},
+		{Text: "due:2022-05-01", Tag: &todo.Description.Tags[2]},
+	}
+	if len(todo.Description.Segments) != len(expected) {
+		t.Fatalf("Expected %d segments, but got: %v", len(expected), todo.Description.Segments)
+	}
+	for i, seg := range todo.Description.Segments {
+		if seg.Text != expected[i].Text || (seg.Tag == nil) != (expected[i].Tag == nil) ||
+			(seg.Tag != nil && !seg.Tag.equal(*expected[i].Tag)) {
+			t.Errorf("Segment %d is incorrect. Expected: %v, but got: %v", i, expected[i], seg)
+		}
+	}
+}
+
 func Test_Parse_Priority(t *testing.T) {
 	input := "x (A) simple description"
 	expected := "A"
@@ -268,53 +299,156 @@ func Test_Parse_Priority(t *testing.T) {
 	}
 }
 
-func Test_Parse_Bad_Priority_Should_Panic(t *testing.T) {
-	input := "x (AB) simple description"
-	defer func() {
-		if r := recover(); r != nil {
-			fmt.Println("Recovered:", r)
+func Test_Parse_Words_In_Parentheses_Are_Description(t *testing.T) {
+	for _, input := range []string{"x (AB) simple description", "(optional) buy milk", "(a) thing", "(A)B thing"} {
+		todo, err := Parse(input)
+		if err != nil {
+			t.Errorf("Failed to parse %q: %v", input, err)
+			continue
+		}
+		if todo.Priority != nil {
+			t.Errorf("%q should have no priority, but got: %q", input, *todo.Priority)
+		}
+		if expected := strings.TrimPrefix(input, "x "); todo.Description.Text != expected {
+			t.Errorf("Description text is incorrect. Expected: %q, but got: %q", expected, todo.Description.Text)
 		}
-	}()
-	Parse(input)
+	}
 }
 
-func Parse_Completion_Date(t *testing.T) {
+func Test_Parse_Creation_Date_Of_Incomplete_Todo(t *testing.T) {
 	input := "2016-05-20 simple description"
 	expected := "2016-05-20"
 	todo, err := Parse(input)
-	fmt.Printf("Todo: %v\n", todo)
 
-	if todo.CompletionDate == nil || err != nil {
-		t.Errorf("Priority is incorrect. Expected: \"%s\", but got: \"%s\"\n", expected, (*todo.CompletionDate).Format(YYYYMMDD))
+	if err != nil || todo.CreationDate.Format(YYYYMMDD) != expected || todo.CompletionDate != nil {
+		t.Errorf("Bad creation date. Expected: \"%s\", but got: %v\n", expected, todo)
+	}
+	if todo.Description.Text != "simple description" {
+		t.Errorf("Date should not be part of the description. Got: %q", todo.Description.Text)
 	}
 }
 
 func Test_Parse_Creation_Date(t *testing.T) {
 	input := "x (A) 2022-04-20 2022-04-21 update screenshots +proj"
-	createDate := time.Now().Format(YYYYMMDD)
-	complDate := "2022-04-21"
+	createDate := "2022-04-21"
+	complDate := "2022-04-20"
 	todo, err := Parse(input)
-	fmt.Printf("Todo: %v\n", todo)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if strings.Compare(todo.CreationDate.Format(YYYYMMDD), createDate) != 0 {
 		t.Errorf("Bad creation date. Expected: \"%s\", but got: \"%s\"\n", createDate, (todo.CreationDate).Format(YYYYMMDD))
 	}
 
-	if todo.CompletionDate == nil || err != nil {
-		t.Errorf("Bad completion date. Expected: \"%s\", but got: \"%s\"\n", complDate, (*todo.CompletionDate).Format(YYYYMMDD))
+	if todo.CompletionDate == nil || todo.CompletionDate.Format(YYYYMMDD) != complDate {
+		t.Errorf("Bad completion date. Expected: \"%s\", but got: %v\n", complDate, todo.CompletionDate)
+	}
+}
+
+func Test_Parse_Dates(t *testing.T) {
+	testcases := []struct {
+		input, creation, completion, text string
+	}{
+		{"simple description", "", "", "simple description"},
+		{"(A) simple description", "", "", "simple description"},
+		{"x simple description", "", "", "simple description"},
+		{"x 2011-03-03 Call Mom", "", "2011-03-03", "Call Mom"},
+		{"x 2011-03-02 2011-03-01 Review Tim's pull request", "2011-03-01", "2011-03-02", "Review Tim's pull request"},
+		{"2011-03-02 Document task format", "2011-03-02", "", "Document task format"},
+		{"(A) 2022-04-20 2022-04-22 update screenshots", "2022-04-20", "", "2022-04-22 update screenshots"},
+		{"x (B) 2022-04-20 2022-04-22 walk dog", "2022-04-22", "2022-04-20", "walk dog"},
+		{"call on 2022-04-20", "", "", "call on 2022-04-20"},
+	}
+
+	format := func(d time.Time) string {
+		if d.IsZero() {
+			return ""
+		}
+		return d.Format(YYYYMMDD)
+	}
+
+	for _, tc := range testcases {
+		todo, err := Parse(tc.input)
+		if err != nil {
+			t.Errorf("Failed to parse %q: %v", tc.input, err)
+			continue
+		}
+
+		if got := format(todo.CreationDate); got != tc.creation {
+			t.Errorf("Bad creation date for %q. Expected: %q, but got: %q", tc.input, tc.creation, got)
+		}
+		completion := ""
+		if todo.CompletionDate != nil {
+			completion = format(*todo.CompletionDate)
+		}
+		if completion != tc.completion {
+			t.Errorf("Bad completion date for %q. Expected: %q, but got: %q", tc.input, tc.completion, completion)
+		}
+		if todo.Description.Text != tc.text {
+			t.Errorf("Bad description for %q. Expected: %q, but got: %q", tc.input, tc.text, todo.Description.Text)
+		}
+	}
+}
+
+func Test_Parse_Bad_Date_Should_Fail(t *testing.T) {
+	testcases := []struct {
+		input  string
+		column int
+		token  string
+	}{
+		{"2015-13-20 simple description", 1, "2015-13-20"},
+		{"2015-02-30 simple due:now @ctx1 +proj1", 1, "2015-02-30"},
+		{"x 2015-13-20 simple @ctx1 +proj1", 3, "2015-13-20"},
+		{"x 2015-05-20 2015-00-01 simple", 14, "2015-00-01"},
+		{"  2015-13-20 simple", 3, "2015-13-20"},
+	}
+
+	for _, tc := range testcases {
+		_, err := Parse(tc.input)
+
+		perr, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("Bad date in %q should return a *ParseError, but got: %v", tc.input, err)
+			continue
+		}
+		if perr.Column != tc.column || perr.Token != tc.token {
+			t.Errorf("Parse error for %q points to the wrong token. Expected: %d %q, but got: %d %q", tc.input, tc.column, tc.token, perr.Column, perr.Token)
+		}
+	}
+}
+
+func Test_Parse_Date_Like_Words_Are_Description(t *testing.T) {
+	for _, input := range []string{"2015-5-20 simple description", "20-05-20 simple", "2015--20 simple", "2022-05 report", "x 1-2-3 go"} {
+		todo, err := Parse(input)
+		if err != nil {
+			t.Errorf("Failed to parse %q: %v", input, err)
+			continue
+		}
+		if !todo.CreationDate.IsZero() || todo.CompletionDate != nil {
+			t.Errorf("%q should have no dates, but got: %v, %v", input, todo.CreationDate, todo.CompletionDate)
+		}
+		if expected := strings.TrimPrefix(input, "x "); todo.Description.Text != expected {
+			t.Errorf("Description text is incorrect. Expected: %q, but got: %q", expected, todo.Description.Text)
+		}
+	}
+}
+
+func Test_Parse_Empty_Todo_Should_Fail(t *testing.T) {
+	for _, input := range []string{"", "   "} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parsing empty todo %q should return an error.", input)
+		}
 	}
 }
 
-func Test_Parse_Bad_Date_Should_Panic(t *testing.T) {
-	inputs := []string{"2015-5-20 simple description", "20-05-20 simple description", "2015--20 simple description", "2015-20 simple due:now @ctx1 +proj1", "x 2015-20 simple @ctx1 +proj1"}
+func Test_Parse_Error_Caret(t *testing.T) {
+	input := "x γάλα due:"
+	perr := &ParseError{Column: strings.Index(input, "due:") + 1, Input: input}
 
-	for _, input := range inputs {
-		defer func() {
-			if r := recover(); r != nil {
-				fmt.Println("Recovered:", r)
-			}
-		}()
-		Parse(input)
+	expected := "x γάλα due:\n       ^"
+	if got := perr.Caret(); got != expected {
+		t.Errorf("Caret is misplaced. Expected:\n%s\nbut got:\n%s", expected, got)
 	}
 }
 
@@ -371,3 +505,120 @@ func Test_Parse_Complete_Todo_Input(t *testing.T) {
 		t.Error("Failed to parse key value tag.")
 	}
 }
+
+func Test_Parse_Greek_And_Emoji_Tags(t *testing.T) {
+	input := "(B) Αγόρασε γάλα +σπίτι @σούπερ_μάρκετ 🛒 +🎉 @📞 κατάστημα:γωνία"
+	todo, err := Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"+σπίτι", "@σούπερ_μάρκετ", "+🎉", "@📞", "κατάστημα:γωνία"}
+	if len(todo.Description.Tags) != len(expected) {
+		t.Fatalf("Expected %d tags, but got: %v", len(expected), todo.Description.Tags)
+	}
+	for i, tag := range todo.Description.Tags {
+		if tag.Format() != expected[i] {
+			t.Errorf("Tag is incorrect. Expected: %q, but got: %q", expected[i], tag.Format())
+		}
+	}
+
+	expectedText := "Αγόρασε γάλα 🛒"
+	if todo.Description.Text != expectedText {
+		t.Errorf("Description text is incorrect. Expected: %q, but got: %q", expectedText, todo.Description.Text)
+	}
+}
+
+func Test_Parse_Unicode_White_Space(t *testing.T) {
+	input := "call customer +proj\tdue:now"
+	todo, err := Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(todo.Description.Tags) != 2 || todo.Description.Text != "call customer" {
+		t.Errorf("Unicode white space should separate words. Todo: %v", todo)
+	}
+}
+
+func largeTodoFile(lines int) string {
+	var b strings.Builder
+	literals := append(todoLiterals(), "(C) Αγόρασε γάλα +σπίτι @σούπερ_μάρκετ 🛒 +🎉")
+	for i := 0; i < lines; i++ {
+		b.WriteString(literals[i%len(literals)])
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func Benchmark_Parse(b *testing.B) {
+	input := "x (A) 2016-04-30 2016-04-01 measure space for +chapelShelving @chapel due:2016-05-30"
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark_Get_All_Large_File(b *testing.B) {
+	file := largeTodoFile(50000)
+	b.SetBytes(int64(len(file)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := GetAll(strings.NewReader(file)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Test_Parse_Colon_Words_That_Arent_Tags(t *testing.T) {
+	testcases := []struct {
+		input, text string
+		links       []string
+	}{
+		{"Read https://go.dev/doc/effective_go", "Read https://go.dev/doc/effective_go", []string{"https://go.dev/doc/effective_go"}},
+		{"Mail mailto:anna@example.com, then ftp://files.example.com.", "Mail mailto:anna@example.com, then ftp://files.example.com.", []string{"mailto:anna@example.com", "ftp://files.example.com"}},
+		{"Standup at 10:30 and 14:00:15", "Standup at 10:30 and 14:00:15", nil},
+		{"Mix paint 3:1", "Mix paint 3:1", nil},
+		{"Remember: buy milk", "Remember: buy milk", nil},
+		{"Note: check", "Note: check", nil},
+		{`Open C:\Users and D:/tmp`, `Open C:\Users and D:/tmp`, nil},
+		{"Read chapter 3.1:intro", "Read chapter 3.1:intro", nil},
+	}
+
+	for _, tc := range testcases {
+		todo, err := Parse(tc.input)
+		if err != nil {
+			t.Errorf("Failed to parse %q: %v", tc.input, err)
+			continue
+		}
+
+		if len(todo.Description.Tags) != 0 {
+			t.Errorf("%q should have no tags, but got: %v", tc.input, todo.Description.Tags)
+		}
+		if todo.Description.Text != tc.text {
+			t.Errorf("Description text is incorrect. Expected: %q, but got: %q", tc.text, todo.Description.Text)
+		}
+		if fmt.Sprint(todo.Links) != fmt.Sprint(tc.links) {
+			t.Errorf("Links are incorrect. Expected: %v, but got: %v", tc.links, todo.Links)
+		}
+	}
+}
+
+func Test_Parse_Key_Value_Tag_Next_To_URL(t *testing.T) {
+	todo, err := Parse("Review https://example.com/pr/1 due:2022-05-01 10:30")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kvTag := getFirstTagOfType(todo.Description.Tags, KeyValue)
+	if len(todo.Description.Tags) != 1 || kvTag == nil || *kvTag.Key != "due" {
+		t.Errorf("Expected a single due tag, but got: %v", todo.Description.Tags)
+	}
+	if len(todo.Links) != 1 {
+		t.Errorf("Expected a single link, but got: %v", todo.Links)
+	}
+}
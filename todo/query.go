@@ -0,0 +1,376 @@
+package todo
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// queryToken is a word or a parenthesis of a query.
+type queryToken struct {
+	value string
+	pos   int
+	// Whether the whole token was quoted, which makes it plain text
+	quoted bool
+}
+
+// queryParser compiles a query, which is a tokenized expression, into a
+// Filter. See Compile for the syntax.
+type queryParser struct {
+	expr   string
+	tokens []queryToken
+	curr   int
+}
+
+// Compile returns the Filter selecting the todos matching the query expr.
+// A query is made of terms combined with and, or, not and parentheses.
+// Terms next to each other must all match, as with and. A term is one of:
+//
+//	+project, @context   the todo has the tag, ignoring case
+//	done                 the todo is complete
+//	key:value            comparisons of the values of key with value, the
+//	key=value            operator being one of : = != < <= > >= and ~
+//	key<value ...        which matches a regular expression
+//	word, "some words"   the description contains the text, ignoring case
+//
+// Keys are key value tags, e.g. due, or one of pri, created, completed,
+// project, context and text. Values are compared as dates when written as
+// YYYY-MM-DD, as numbers when they are numbers and as text otherwise.
+// Dates of due, created and completed can be relative, e.g. due<=tomorrow,
+// see ResolveDate. Priorities compare by importance, A being the greatest.
+// A todo without value for a key matches none of its comparisons but !=,
+// e.g.
+//
+//	+work and @phone and pri>=B and due<2022-05-01 and not done
+//	(project~^garage or @store) and not "pick up"
+func Compile(expr string) (Filter, error) {
+	tokens, err := scanQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{expr: expr, tokens: tokens}
+
+	if len(tokens) == 0 {
+		return nil, p.errorAt(queryToken{pos: len(expr)}, "empty query")
+	}
+	f, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, p.errorAt(tok, "unexpected token")
+	}
+	return f, nil
+}
+
+// scanQuery splits expr into words and parentheses. Quoted parts of a word
+// are kept as they are, including white space and parentheses.
+func scanQuery(expr string) ([]queryToken, error) {
+	tokens := make([]queryToken, 0)
+	curr := 0
+	for {
+		curr = skipWhiteSpace(curr, expr)
+		if isAtEnd(curr, expr) {
+			return tokens, nil
+		}
+
+		if expr[curr] == '(' || expr[curr] == ')' {
+			tokens = append(tokens, queryToken{value: expr[curr : curr+1], pos: curr})
+			curr++
+			continue
+		}
+
+		start := curr
+		var b strings.Builder
+		for !isAtEnd(curr, expr) {
+			r, size := runeAt(curr, expr)
+			if unicode.IsSpace(r) || r == '(' || r == ')' {
+				break
+			}
+			if r != '"' {
+				b.WriteRune(r)
+				curr += size
+				continue
+			}
+
+			end := strings.IndexByte(expr[curr+1:], '"')
+			if end < 0 {
+				return nil, &ParseError{Column: curr + 1, Token: expr[curr:], Reason: "missing closing quote", Input: expr}
+			}
+			b.WriteString(expr[curr+1 : curr+1+end])
+			curr += end + 2
+		}
+		tokens = append(tokens, queryToken{value: b.String(), pos: start, quoted: expr[start] == '"'})
+	}
+}
+
+func (p *queryParser) errorAt(tok queryToken, reason string) error {
+	return &ParseError{Column: tok.pos + 1, Token: tok.value, Reason: reason, Input: p.expr}
+}
+
+func (p *queryParser) peek() (queryToken, bool) {
+	if p.curr >= len(p.tokens) {
+		return queryToken{}, false
+	}
+	return p.tokens[p.curr], true
+}
+
+// isKeyword reports whether tok is the given keyword, ignoring case.
+func isKeyword(tok queryToken, keyword string) bool {
+	return !tok.quoted && strings.EqualFold(tok.value, keyword)
+}
+
+// or parses terms joined by or.
+func (p *queryParser) or() (Filter, error) {
+	f, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.peek()
+		if !ok || !isKeyword(tok, "or") {
+			return f, nil
+		}
+		p.curr++
+
+		left := f
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		f = func(t *Todo) bool { return left(t) || right(t) }
+	}
+}
+
+// and parses terms joined by and, or simply written next to each other.
+func (p *queryParser) and() (Filter, error) {
+	f, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.value == ")" || isKeyword(tok, "or") {
+			return f, nil
+		}
+		if isKeyword(tok, "and") {
+			p.curr++
+		}
+
+		left := f
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		f = func(t *Todo) bool { return left(t) && right(t) }
+	}
+}
+
+// not parses a negated term, a parenthesized query or a term.
+func (p *queryParser) not() (Filter, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, p.errorAt(queryToken{pos: len(p.expr)}, "query ends early")
+	}
+	p.curr++
+
+	switch {
+	case isKeyword(tok, "not"):
+		f, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return func(t *Todo) bool { return !f(t) }, nil
+	case tok.value == "(" && !tok.quoted:
+		f, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if closing, ok := p.peek(); !ok || closing.value != ")" {
+			return nil, p.errorAt(tok, "missing closing parenthesis")
+		}
+		p.curr++
+		return f, nil
+	case tok.value == ")" && !tok.quoted,
+		isKeyword(tok, "and"), isKeyword(tok, "or"):
+		return nil, p.errorAt(tok, "expected a term")
+	}
+	return p.term(tok)
+}
+
+// term parses a tag, a comparison or a text term.
+func (p *queryParser) term(tok queryToken) (Filter, error) {
+	v := tok.value
+	switch {
+	case tok.quoted:
+		return textFilter(v), nil
+	case isKeyword(tok, "done"):
+		return func(t *Todo) bool { return t.Done }, nil
+	case len(v) > 1 && (v[0] == '+' || v[0] == '@'):
+		tag := Tag{TagType: Project, Value: v[1:]}
+		if v[0] == '@' {
+			tag.TagType = Context
+		}
+		return func(t *Todo) bool { return t.HasTag(tag) }, nil
+	}
+
+	i := strings.IndexAny(v, "<>=!~:")
+	if i < 1 || (v[i] == ':' && !isKeyValue(v)) || (v[i] == '!' && !strings.HasPrefix(v[i:], "!=")) {
+		return textFilter(v), nil
+	}
+
+	key, op := strings.ToLower(v[:i]), v[i:i+1]
+	if strings.HasPrefix(v[i:], "<=") || strings.HasPrefix(v[i:], ">=") || strings.HasPrefix(v[i:], "!=") {
+		op = v[i : i+2]
+	}
+	value := v[i+len(op):]
+	if len(value) == 0 {
+		return nil, p.errorAt(tok, "missing value to compare "+key+" with")
+	}
+	if key == PriorityKey && op != "~" && !ValidPriority(strings.ToUpper(value)) {
+		return nil, p.errorAt(tok, "bad priority value, expected a letter (A-Z)")
+	}
+	if op != "~" && (isDateKey(key) || key == "created" || key == "completed") {
+		if date, ok := ResolveDate(value, time.Now()); ok {
+			value = date.Format(YYYYMMDD)
+		}
+	}
+
+	var match func(string) bool
+	switch op {
+	case "~":
+		re, err := regexp.Compile("(?i)" + value)
+		if err != nil {
+			return nil, p.errorAt(tok, "bad regular expression")
+		}
+		match = re.MatchString
+	case ":", "=", "!=":
+		match = func(got string) bool {
+			c, ok := compareValues(key, got, value)
+			return ok && c == 0
+		}
+	default:
+		match = func(got string) bool {
+			c, ok := compareValues(key, got, value)
+			switch {
+			case !ok:
+				return false
+			case op == "<":
+				return c < 0
+			case op == "<=":
+				return c <= 0
+			case op == ">":
+				return c > 0
+			}
+			return c >= 0
+		}
+	}
+
+	f := func(t *Todo) bool {
+		for _, got := range t.values(key) {
+			if match(got) {
+				return true
+			}
+		}
+		return false
+	}
+	if op == "!=" {
+		return func(t *Todo) bool { return !f(t) }, nil
+	}
+	return f, nil
+}
+
+func textFilter(text string) Filter {
+	text = strings.ToLower(text)
+	return func(t *Todo) bool {
+		return strings.Contains(strings.ToLower(t.Description.Text), text)
+	}
+}
+
+// values returns the values of key a query compares, see Compile.
+func (t Todo) values(key string) []string {
+	var values []string
+	switch key {
+	case PriorityKey:
+		if t.Priority != nil {
+			return []string{*t.Priority}
+		}
+	case "created":
+		if !t.CreationDate.IsZero() {
+			return []string{t.CreationDate.Format(YYYYMMDD)}
+		}
+		return nil
+	case "completed":
+		if t.CompletionDate != nil {
+			return []string{t.CompletionDate.Format(YYYYMMDD)}
+		}
+		return nil
+	case "text":
+		return []string{t.Description.Text}
+	case "project", "context":
+		tagType := Project
+		if key == "context" {
+			tagType = Context
+		}
+		for _, tg := range t.Description.Tags {
+			if tg.TagType == tagType {
+				values = append(values, tg.Value)
+			}
+		}
+		return values
+	}
+
+	for _, tg := range t.Description.Tags {
+		if tg.TagType == KeyValue && tg.Key != nil && strings.EqualFold(*tg.Key, key) {
+			values = append(values, tg.Value)
+		}
+	}
+	return values
+}
+
+// compareValues compares got, a value of key, with the value of a query.
+// It reports false when they can't be compared, e.g. got isn't a date
+// while value is.
+func compareValues(key, got, value string) (int, bool) {
+	if key == PriorityKey {
+		got, value = strings.ToUpper(got), strings.ToUpper(value)
+		if !ValidPriority(got) {
+			return 0, false
+		}
+		// A is the greatest priority
+		return strings.Compare(value, got), true
+	}
+
+	if date, err := time.Parse(YYYYMMDD, value); err == nil {
+		gotDate, err := time.Parse(YYYYMMDD, got)
+		if err != nil {
+			return 0, false
+		}
+		switch {
+		case gotDate.Before(date):
+			return -1, true
+		case gotDate.After(date):
+			return 1, true
+		}
+		return 0, true
+	}
+
+	if n, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(n) {
+		gotN, err := strconv.ParseFloat(got, 64)
+		if err != nil {
+			return 0, false
+		}
+		switch {
+		case gotN < n:
+			return -1, true
+		case gotN > n:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	return strings.Compare(strings.ToLower(got), strings.ToLower(value)), true
+}
@@ -0,0 +1,83 @@
+package todo
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_Compile_Query(t *testing.T) {
+	todos := loadTodos(t, append(todoLiterals(), "(C) 2022-04-01 Call plumber +House @phone due:2022-04-28 est:3", "Fix sink +House est:12")...)
+
+	testcases := []struct {
+		expr     string
+		expected int
+	}{
+		{"+GarageSale", 2},
+		{"+garagesale @phone", 1},
+		{"+garagesale and @phone", 1},
+		{"@phone and pri>=B and not done", 3},
+		{"@phone and pri>=B and due<2022-05-01 and not done", 0},
+		{"@phone and due<2022-05-01 and not done", 1},
+		{"pri=a or +house", 6},
+		{"(pri=a or +house) and est>5", 1},
+		{"est>=3 est<=3", 1},
+		{"project~^garage or @store", 2},
+		{"context~phone$", 5},
+		{"due:now", 1},
+		{"due!=now", 13},
+		{"created<2018-01-01", 2},
+		{"completed>=2011-03-03", 2},
+		{"done and not (+project or @github)", 2},
+		{"mom", 3},
+		{`"call mom"`, 2},
+		{`text~"call (mom|plumber)"`, 3},
+		{"not not done", 4},
+	}
+
+	for _, tc := range testcases {
+		match, err := Compile(tc.expr)
+		if err != nil {
+			t.Errorf("Query %q should compile, but got: %v", tc.expr, err)
+			continue
+		}
+
+		found := 0
+		for _, todo := range todos {
+			if match(todo) {
+				found++
+			}
+		}
+		if found != tc.expected {
+			t.Errorf("Query %q should match %d todos, but matched: %d", tc.expr, tc.expected, found)
+		}
+	}
+}
+
+func Test_Compile_Bad_Query(t *testing.T) {
+	testcases := []struct {
+		expr   string
+		column int
+	}{
+		{"", 1},
+		{"+work and", 10},
+		{"(+work or @phone", 1},
+		{"+work)", 6},
+		{"or +work", 1},
+		{"pri>=AB", 1},
+		{"@phone due<", 8},
+		{"text~(", 1},
+		{`"call mom`, 1},
+	}
+
+	for _, tc := range testcases {
+		_, err := Compile(tc.expr)
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("Query %q should fail with a ParseError, but got: %v", tc.expr, err)
+			continue
+		}
+		if perr.Column != tc.column {
+			t.Errorf("Query %q should fail at column %d, but got: %d (%v)", tc.expr, tc.column, perr.Column, perr)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package todo
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Record is the machine-readable form of a todo written by WriteRecords.
+type Record struct {
+	Line      int               `json:"line"`
+	ID        string            `json:"id"`
+	Done      bool              `json:"done"`
+	Priority  string            `json:"priority,omitempty"`
+	Created   string            `json:"created,omitempty"`
+	Completed string            `json:"completed,omitempty"`
+	Due       string            `json:"due,omitempty"`
+	Text      string            `json:"text"`
+	Projects  []string          `json:"projects"`
+	Contexts  []string          `json:"contexts"`
+	Values    map[string]string `json:"values"`
+	Links     []string          `json:"links"`
+}
+
+// recordColumns are the columns of the records written as CSV or TSV.
+var recordColumns = []string{"line", "id", "done", "priority", "created", "completed", "due", "text", "projects", "contexts", "values", "links"}
+
+// NewRecord returns the record of t. Dates are written as YYYY-MM-DD, the
+// priority of a complete todo is the one kept in its pri: tag and values
+// hold the first value of each key of its key value tags.
+func NewRecord(t *Todo) Record {
+	r := Record{
+		Line:     t.Line,
+		ID:       t.ID(),
+		Done:     t.Done,
+		Text:     t.Description.Text,
+		Projects: []string{},
+		Contexts: []string{},
+		Values:   map[string]string{},
+		Links:    append([]string{}, t.Links...),
+	}
+	if t.Priority != nil {
+		r.Priority = *t.Priority
+	} else if pri, ok := t.Value(PriorityKey); ok && t.Done {
+		r.Priority = pri
+	}
+	if !t.CreationDate.IsZero() {
+		r.Created = t.CreationDate.Format(YYYYMMDD)
+	}
+	if t.CompletionDate != nil {
+		r.Completed = t.CompletionDate.Format(YYYYMMDD)
+	}
+	if due, ok := t.Due(); ok {
+		r.Due = due.Format(YYYYMMDD)
+	}
+
+	for _, tg := range t.Description.Tags {
+		switch tg.TagType {
+		case Project:
+			r.Projects = append(r.Projects, tg.Value)
+		case Context:
+			r.Contexts = append(r.Contexts, tg.Value)
+		case KeyValue:
+			if _, ok := r.Values[*tg.Key]; !ok {
+				r.Values[*tg.Key] = tg.Value
+			}
+		}
+	}
+	return r
+}
+
+// fields returns the values of the record's columns, see recordColumns.
+// Lists are separated by spaces and values are written as key:value.
+func (r Record) fields() []string {
+	values := make([]string, 0, len(r.Values))
+	for k, v := range r.Values {
+		values = append(values, k+":"+v)
+	}
+	sort.Strings(values)
+	return []string{
+		strconv.Itoa(r.Line), r.ID, strconv.FormatBool(r.Done), r.Priority, r.Created, r.Completed, r.Due, r.Text,
+		strings.Join(r.Projects, " "), strings.Join(r.Contexts, " "), strings.Join(values, " "), strings.Join(r.Links, " "),
+	}
+}
+
+// OutputFormats are the formats WriteRecords writes.
+var OutputFormats = []string{"json", "jsonl", "csv", "tsv"}
+
+// WriteRecords writes the records of todos to w in the given format:
+//
+//	json    a JSON array of records
+//	jsonl   one JSON record per line
+//	csv     comma separated values, starting with a header line
+//	tsv     tab separated values, starting with a header line
+func WriteRecords(w io.Writer, format string, todos []*Todo) error {
+	records := make([]Record, 0, len(todos))
+	for _, t := range todos {
+		records = append(records, NewRecord(t))
+	}
+
+	switch strings.ToLower(format) {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case "jsonl":
+		enc := json.NewEncoder(w)
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv", "tsv":
+		cw := csv.NewWriter(w)
+		if strings.EqualFold(format, "tsv") {
+			cw.Comma = '\t'
+		}
+		cw.Write(recordColumns)
+		for _, r := range records {
+			cw.Write(r.fields())
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unknown output format %q, expected one of %s", format, strings.Join(OutputFormats, ", "))
+}
@@ -0,0 +1,76 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var recordLines = []string{
+	"(A) 2022-04-20 Call Mom +Family @phone due:2022-05-01 id:3",
+	"x 2022-04-27 Pay rent, see https://bank.example +House pri:B",
+}
+
+func Test_New_Record(t *testing.T) {
+	todos := loadTodos(t, recordLines...)
+
+	expected := Record{
+		Line: 1, ID: "id:3", Priority: "A", Created: "2022-04-20", Due: "2022-05-01", Text: "Call Mom",
+		Projects: []string{"Family"}, Contexts: []string{"phone"},
+		Values: map[string]string{"due": "2022-05-01", "id": "3"}, Links: []string{},
+	}
+	if got := NewRecord(todos[0]); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected record: %+v, but got: %+v", expected, got)
+	}
+
+	got := NewRecord(todos[1])
+	if !got.Done || got.Priority != "B" || got.Completed != "2022-04-27" || got.ID != "2" {
+		t.Errorf("Record of a complete todo is incorrect: %+v", got)
+	}
+}
+
+func Test_Write_Records(t *testing.T) {
+	todos := loadTodos(t, recordLines...)
+
+	var b bytes.Buffer
+	if err := WriteRecords(&b, "json", todos); err != nil {
+		t.Fatal(err)
+	}
+	var records []Record
+	if err := json.Unmarshal(b.Bytes(), &records); err != nil || len(records) != 2 || records[1].Text != NewRecord(todos[1]).Text {
+		t.Errorf("JSON output should hold 2 records, but got: %s (%v)", b.String(), err)
+	}
+
+	b.Reset()
+	if err := WriteRecords(&b, "jsonl", todos); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(b.String()), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[0], `{"line":1,"id":"id:3"`) {
+		t.Errorf("JSON lines output is incorrect: %q", b.String())
+	}
+
+	b.Reset()
+	if err := WriteRecords(&b, "csv", todos); err != nil {
+		t.Fatal(err)
+	}
+	expected := "line,id,done,priority,created,completed,due,text,projects,contexts,values,links\n" +
+		"1,id:3,false,A,2022-04-20,,2022-05-01,Call Mom,Family,phone,due:2022-05-01 id:3,\n" +
+		"2,2,true,B,,2022-04-27,,\"Pay rent, see https://bank.example\",House,,pri:B,https://bank.example\n"
+	if got := b.String(); got != expected {
+		t.Errorf("CSV output is incorrect. Expected: %q, but got: %q", expected, got)
+	}
+
+	b.Reset()
+	if err := WriteRecords(&b, "tsv", todos[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "1\tid:3\tfalse\tA\t") {
+		t.Errorf("TSV output is incorrect: %q", b.String())
+	}
+
+	if err := WriteRecords(&b, "xml", todos); err == nil {
+		t.Error("Writing records as xml should fail.")
+	}
+}
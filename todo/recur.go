@@ -0,0 +1,91 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RecurKey is the key of the tag making a todo come back once complete,
+// e.g. rec:1w
+const RecurKey = "rec"
+
+// Recur returns the next occurrence of the todo, once completed on the date
+// of completed, and reports whether it has one, which it does when it has a
+// rec: tag. Its value is a number of days, weeks, months, years or business
+// days, e.g. 3d, 1w, 1m, 1y or 2b.
+//
+// Following the todo.txt extensions convention, the due date of the next
+// occurrence is counted from the completion date, its threshold date
+// keeping the same distance to it. A value starting with a plus sign, e.g.
+// rec:+1m, makes the recurrence strict: the due and threshold dates are
+// counted from the ones of the todo instead.
+//
+// The next occurrence is open, keeps the priority of the todo and is
+// created on the completion date when the todo has a creation date. It has
+// no line number nor persistent id.
+func (t Todo) Recur(completed time.Time) (*Todo, bool, error) {
+	value, ok := t.Value(RecurKey)
+	if !ok {
+		return nil, false, nil
+	}
+	strict := strings.HasPrefix(value, "+")
+	n, unit, ok := parseDuration(strings.TrimPrefix(value, "+"))
+	if !ok || n < 1 {
+		return nil, false, fmt.Errorf("bad recurrence %q of todo %s, expected e.g. 1w, +1m or 2b", value, t.ID())
+	}
+
+	next, err := Parse(t.Format())
+	if err != nil {
+		return nil, false, err
+	}
+	if next.Done {
+		next.Reopen()
+	}
+	next.RemoveValue(IDKey)
+	next.Line = 0
+
+	today := dateOf(completed)
+	if !next.CreationDate.IsZero() {
+		next.CreationDate = today
+	}
+
+	due, hasDue := t.Due()
+	threshold, hasThreshold := t.Threshold()
+	switch {
+	case strict:
+		if hasDue {
+			next.SetValue(DueKey, addDuration(due, n, unit).Format(YYYYMMDD))
+		}
+		if hasThreshold {
+			next.SetValue(ThresholdKey, addDuration(threshold, n, unit).Format(YYYYMMDD))
+		}
+	case hasDue:
+		nextDue := addDuration(today, n, unit)
+		next.SetValue(DueKey, nextDue.Format(YYYYMMDD))
+		if hasThreshold {
+			days := int(nextDue.Sub(due).Hours() / 24)
+			next.SetValue(ThresholdKey, threshold.AddDate(0, 0, days).Format(YYYYMMDD))
+		}
+	case hasThreshold:
+		next.SetValue(ThresholdKey, addDuration(today, n, unit).Format(YYYYMMDD))
+	}
+
+	next.Original = next.Format()
+	return next, true, nil
+}
+
+// addBusinessDays adds n days to date, skipping Saturdays and Sundays.
+func addBusinessDays(date time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for n > 0 {
+		date = date.AddDate(0, 0, step)
+		if wd := date.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			n--
+		}
+	}
+	return date
+}
@@ -0,0 +1,51 @@
+package todo
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Recur(t *testing.T) {
+	testcases := []struct{ input, expected string }{
+		{"x 2022-04-27 Water plants due:2022-04-25 rec:1w id:3", "Water plants due:2022-05-04 rec:1w"},
+		{"x 2022-04-27 Pay rent due:2022-04-01 rec:+1m", "Pay rent due:2022-05-01 rec:+1m"},
+		{"x 2022-04-27 Pay rent t:2022-03-25 due:2022-04-01 rec:+1m", "Pay rent t:2022-04-25 due:2022-05-01 rec:+1m"},
+		{"x 2022-04-27 Pay rent t:2022-04-20 due:2022-04-25 rec:1w", "Pay rent t:2022-04-29 due:2022-05-04 rec:1w"},
+		{"x 2022-04-27 Review PRs t:2022-04-20 rec:2b", "Review PRs t:2022-04-29 rec:2b"},
+		{"x 2022-04-27 Send report due:2022-04-27 rec:3b", "Send report due:2022-05-02 rec:3b"},
+		{"x 2022-04-27 2022-04-01 Call Mom +Family pri:A rec:1w", "(A) 2022-04-27 Call Mom +Family rec:1w"},
+	}
+	completed := time.Date(2022, 4, 27, 18, 30, 0, 0, time.Local)
+
+	for _, tc := range testcases {
+		todo, err := Parse(tc.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		todo.Line = 2
+
+		next, ok, err := todo.Recur(completed)
+		if err != nil || !ok {
+			t.Fatalf("%q should recur, but got: %v, %v", tc.input, ok, err)
+		}
+		if next.Original != tc.expected || next.Line != 0 || next.Done {
+			t.Errorf("Expected next occurrence: %q, but got: %q", tc.expected, next.Original)
+		}
+	}
+}
+
+func Test_Recur_Without_Or_Bad_Rec_Tag(t *testing.T) {
+	completed := time.Date(2022, 4, 27, 0, 0, 0, 0, time.UTC)
+
+	todo, _ := Parse("x 2022-04-27 Call Mom due:2022-04-25")
+	if next, ok, err := todo.Recur(completed); ok || next != nil || err != nil {
+		t.Errorf("Todo without rec: tag shouldn't recur, but got: %v, %v", next, err)
+	}
+
+	for _, rec := range []string{"weekly", "0d", "-1w", "+1x"} {
+		todo, _ := Parse("x 2022-04-27 Call Mom rec:" + rec)
+		if _, _, err := todo.Recur(completed); err == nil {
+			t.Errorf("Recurrence %q should be rejected.", rec)
+		}
+	}
+}
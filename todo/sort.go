@@ -0,0 +1,135 @@
+package todo
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SortKey is a key todos are sorted by, see ParseSortKeys.
+type SortKey struct {
+	Name       string
+	Descending bool
+}
+
+// sortKeys are the keys ParseSortKeys knows of, the ones of key value tags
+// being written with a colon.
+var sortKeys = []string{"priority", PriorityKey, "done", "created", "completed", "text", "project", "context", DueKey, ThresholdKey, RecurKey, IDKey}
+
+// ParseSortKeys parses a comma separated list of sort keys, each followed by
+// :asc or :desc for its direction, ascending by default, e.g.
+// "priority,due:desc". Keys are the ones of queries (see Compile) as well as
+// priority and done, which sorts todos by completion status. The key of any
+// other key value tag is followed by a colon, e.g. "est:" or "est:desc",
+// so that a misspelled key is an error.
+func ParseSortKeys(list string) ([]SortKey, error) {
+	keys := make([]SortKey, 0)
+	for _, field := range strings.Split(list, ",") {
+		name, dir, tag := strings.Cut(strings.TrimSpace(field), ":")
+		key := SortKey{Name: strings.ToLower(name)}
+		if len(key.Name) == 0 {
+			return nil, fmt.Errorf("empty sort key in %q", list)
+		}
+		if !tag && !isSortKey(key.Name) {
+			return nil, fmt.Errorf("unknown sort key %q, expected one of %s, or the key of key value tags followed by a colon, e.g. est:", name, strings.Join(sortKeys, ", "))
+		}
+		if key.Name == "priority" {
+			key.Name = PriorityKey
+		}
+
+		switch strings.ToLower(dir) {
+		case "", "asc":
+		case "desc":
+			key.Descending = true
+		default:
+			return nil, fmt.Errorf("bad direction %q of sort key %s, expected asc or desc", dir, name)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func isSortKey(name string) bool {
+	for _, k := range sortKeys {
+		if k == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Sort sorts todos by keys, the first key first. Todos without a value for
+// a key come after the ones having one, whatever the direction. Sorting is
+// stable, todos with equal keys keep their order.
+//
+// Priorities sort from A to Z, dates chronologically, numbers by value,
+// complete todos after the others, and anything else as text, ignoring case.
+func Sort(todos []*Todo, keys []SortKey) {
+	sort.SliceStable(todos, func(i, j int) bool {
+		for _, key := range keys {
+			a, aOk := todos[i].sortValue(key.Name)
+			b, bOk := todos[j].sortValue(key.Name)
+			switch {
+			case !aOk && !bOk:
+				continue
+			case !aOk:
+				return false
+			case !bOk:
+				return true
+			}
+
+			c := compareSortValues(a, b)
+			if key.Descending {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+}
+
+// sortValue returns the value of key todos are sorted by.
+func (t Todo) sortValue(key string) (string, bool) {
+	if key == "done" {
+		return strconv.FormatBool(t.Done), true
+	}
+	values := t.values(key)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+// compareSortValues compares a and b as dates or numbers when they both are,
+// as text otherwise.
+func compareSortValues(a, b string) int {
+	if aDate, err := time.Parse(YYYYMMDD, a); err == nil {
+		if bDate, err := time.Parse(YYYYMMDD, b); err == nil {
+			switch {
+			case aDate.Before(bDate):
+				return -1
+			case aDate.After(bDate):
+				return 1
+			}
+			return 0
+		}
+	}
+
+	if aN, err := strconv.ParseFloat(a, 64); err == nil {
+		if bN, err := strconv.ParseFloat(b, 64); err == nil {
+			switch {
+			case aN < bN:
+				return -1
+			case aN > bN:
+				return 1
+			}
+			return 0
+		}
+	}
+
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
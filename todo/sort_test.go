@@ -0,0 +1,57 @@
+package todo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Sort(t *testing.T) {
+	lines := []string{
+		"Fix sink +House est:12",
+		"(B) Call Mom due:2022-05-02",
+		"x 2022-04-01 (A) Pay rent +House",
+		"(A) Call plumber +House due:2022-04-28 est:3",
+		"(B) Buy milk @store",
+		"2022-04-01 Water plants est:1",
+	}
+
+	testcases := []struct {
+		keys     string
+		expected []int
+	}{
+		{"priority", []int{3, 1, 4, 0, 2, 5}},
+		{"priority:desc", []int{1, 4, 3, 0, 2, 5}},
+		{"done,priority", []int{3, 1, 4, 0, 5, 2}},
+		{"due", []int{3, 1, 0, 2, 4, 5}},
+		{"due:desc", []int{1, 3, 0, 2, 4, 5}},
+		{"est:", []int{5, 3, 0, 1, 2, 4}},
+		{"EST:desc", []int{0, 3, 5, 1, 2, 4}},
+		{"project,text", []int{2, 3, 0, 4, 1, 5}},
+		{"created:desc", []int{5, 0, 1, 2, 3, 4}},
+	}
+
+	for _, tc := range testcases {
+		todos := loadTodos(t, lines...)
+		keys, err := ParseSortKeys(tc.keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		Sort(todos, keys)
+
+		got := make([]int, 0, len(todos))
+		for _, todo := range todos {
+			got = append(got, todo.Line-1)
+		}
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("Sorting by %q should give lines %v, but got: %v", tc.keys, tc.expected, got)
+		}
+	}
+}
+
+func Test_Parse_Bad_Sort_Keys(t *testing.T) {
+	for _, list := range []string{"", "priority,", "due:up", "prioirty", "est"} {
+		if _, err := ParseSortKeys(list); err == nil {
+			t.Errorf("Sort keys %q should be rejected.", list)
+		}
+	}
+}
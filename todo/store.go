@@ -0,0 +1,175 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Store keeps a todo list, one todo.txt line per todo. Todos are addressed
+// by their Line, the 1-based number of the line they were loaded from, so
+// that lines which can't be parsed are kept as they are.
+type Store interface {
+	// Load returns every todo of the store. Lines that couldn't be parsed
+	// are skipped and returned as ParseErrors along with the other todos.
+	Load() ([]*Todo, error)
+	// Append adds todos to the end of the store and sets their Line.
+	Append(todos ...*Todo) error
+	// Update rewrites the lines of todos with their formatted value.
+	Update(todos ...*Todo) error
+	// Delete removes the lines of todos.
+	Delete(todos ...*Todo) error
+	// Replace replaces every line of the store with todos, in order.
+	Replace(todos ...*Todo) error
+}
+
+// MemoryStore is a Store keeping its lines in memory, e.g. to test code
+// working on todos without touching the filesystem.
+type MemoryStore struct {
+	lines []string
+}
+
+// NewMemoryStore returns a store holding the given todo.txt lines.
+func NewMemoryStore(lines ...string) *MemoryStore {
+	return &MemoryStore{lines: append([]string(nil), lines...)}
+}
+
+// Lines returns a copy of the lines of the store.
+func (s *MemoryStore) Lines() []string {
+	return append([]string(nil), s.lines...)
+}
+
+func (s *MemoryStore) Load() ([]*Todo, error) {
+	return GetAll(strings.NewReader(strings.Join(s.lines, "\n")))
+}
+
+func (s *MemoryStore) Append(todos ...*Todo) error {
+	n := len(s.lines)
+	s.lines = appendLines(s.lines, todos)
+	setLines(todos, n)
+	return nil
+}
+
+func (s *MemoryStore) Update(todos ...*Todo) error {
+	lines, err := updateLines(s.lines, todos)
+	if err != nil {
+		return err
+	}
+	s.lines = lines
+	setOriginals(todos, lines)
+	return nil
+}
+
+func (s *MemoryStore) Delete(todos ...*Todo) error {
+	lines, err := deleteLines(s.lines, todos)
+	if err != nil {
+		return err
+	}
+	s.lines = lines
+	return nil
+}
+
+func (s *MemoryStore) Replace(todos ...*Todo) error {
+	s.lines = appendLines(nil, todos)
+	setLines(todos, 0)
+	return nil
+}
+
+// appendLines returns lines followed by the formatted todos.
+func appendLines(lines []string, todos []*Todo) []string {
+	appended := make([]string, len(lines), len(lines)+len(todos))
+	copy(appended, lines)
+	for _, t := range todos {
+		appended = append(appended, t.Format())
+	}
+	return appended
+}
+
+// updateLines returns lines with the lines of todos replaced by their
+// formatted value.
+func updateLines(lines []string, todos []*Todo) ([]string, error) {
+	updated := append([]string(nil), lines...)
+	for _, t := range todos {
+		if t.Line < 1 || t.Line > len(updated) {
+			return nil, fmt.Errorf("%w: no todo at line %d", ErrNotFound, t.Line)
+		}
+		updated[t.Line-1] = t.Format()
+	}
+	return updated, nil
+}
+
+// deleteLines returns lines without the lines of todos.
+func deleteLines(lines []string, todos []*Todo) ([]string, error) {
+	skip := make(map[int]bool, len(todos))
+	for _, t := range todos {
+		if t.Line < 1 || t.Line > len(lines) {
+			return nil, fmt.Errorf("%w: no todo at line %d", ErrNotFound, t.Line)
+		}
+		skip[t.Line] = true
+	}
+
+	kept := make([]string, 0, len(lines))
+	for i, l := range lines {
+		if !skip[i+1] {
+			kept = append(kept, l)
+		}
+	}
+	return kept, nil
+}
+
+// equalLines reports whether a and b hold the same lines.
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// setLines numbers todos written after the first n lines of a store and
+// makes their formatted value their original line.
+func setLines(todos []*Todo, n int) {
+	for i, t := range todos {
+		t.Original = t.Format()
+		t.Line = n + i + 1
+	}
+}
+
+// setOriginals makes the updated lines of todos their original line.
+func setOriginals(todos []*Todo, lines []string) {
+	for _, t := range todos {
+		t.Original = lines[t.Line-1]
+	}
+}
+
+// Archive moves the complete todos of s to the end of done and returns
+// them, numbered with the lines they had in s.
+func Archive(s Store, done Store) ([]*Todo, error) {
+	all, err := s.Load()
+	if _, ok := err.(ParseErrors); err != nil && !ok {
+		return nil, err
+	}
+
+	archived := make([]*Todo, 0)
+	copies := make([]*Todo, 0)
+	for _, t := range all {
+		if t.Done {
+			archived = append(archived, t)
+			c := *t
+			copies = append(copies, &c)
+		}
+	}
+	if len(archived) == 0 {
+		return archived, nil
+	}
+
+	// Append to the done store first, so that a failure can at worst
+	// leave todos in both stores
+	if err := done.Append(copies...); err != nil {
+		return nil, err
+	}
+	return archived, s.Delete(archived...)
+}
@@ -0,0 +1,77 @@
+package todo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func storeLines() []string {
+	return []string{
+		"(A) Call Mom +Family id:1",
+		"",
+		"2022-13-01 bad date",
+		"x 2011-03-03 Call Mom due:now id:2",
+		"Pick up dry cleaning id:3",
+	}
+}
+
+func Test_Memory_Store(t *testing.T) {
+	s := NewMemoryStore(storeLines()...)
+	todos, err := s.Load()
+	if _, ok := err.(ParseErrors); !ok || len(todos) != 3 {
+		t.Fatalf("Expected 3 todos and a parse error, but got: %d, %v", len(todos), err)
+	}
+
+	added, _ := Parse("Buy milk @store")
+	if err := s.Append(added); err != nil || added.Line != 6 {
+		t.Errorf("Appended todo should be at line 6, but got: %d, %v", added.Line, err)
+	}
+
+	todos[0].SetPriority("B")
+	if err := s.Update(todos[0]); err != nil || todos[0].Original != "(B) Call Mom +Family id:1" {
+		t.Errorf("Updated todo should keep its new line, but got: %q, %v", todos[0].Original, err)
+	}
+
+	if err := s.Delete(todos[1], todos[2]); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"(B) Call Mom +Family id:1", "", "2022-13-01 bad date", "Buy milk @store"}
+	if got := s.Lines(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Lines are incorrect. Expected: %q, but got: %q", expected, got)
+	}
+
+	gone := &Todo{Line: 7}
+	if err := s.Update(gone); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Updating a missing line should return ErrNotFound, but got: %v", err)
+	}
+	if err := s.Delete(gone); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Deleting a missing line should return ErrNotFound, but got: %v", err)
+	}
+
+	if err := s.Replace(added, todos[0]); err != nil || todos[0].Line != 2 {
+		t.Errorf("Replaced todo should be at line 2, but got: %d, %v", todos[0].Line, err)
+	}
+	if got := s.Lines(); len(got) != 2 || got[0] != "Buy milk @store" {
+		t.Errorf("Lines should be replaced, but got: %q", got)
+	}
+}
+
+func Test_Archive(t *testing.T) {
+	s := NewMemoryStore(storeLines()...)
+	done := NewMemoryStore("x 2011-03-02 Document +TodoTxt task format")
+
+	archived, err := Archive(s, done)
+	if err != nil || len(archived) != 1 || archived[0].Line != 4 {
+		t.Fatalf("Expected the todo of line 4 to be archived, but got: %v, %v", archived, err)
+	}
+
+	expected := []string{"(A) Call Mom +Family id:1", "", "2022-13-01 bad date", "Pick up dry cleaning id:3"}
+	if got := s.Lines(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Archived todos should be removed. Expected: %q, but got: %q", expected, got)
+	}
+	expected = []string{"x 2011-03-02 Document +TodoTxt task format", "x 2011-03-03 Call Mom due:now id:2"}
+	if got := done.Lines(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Archived todos should be appended. Expected: %q, but got: %q", expected, got)
+	}
+}
@@ -0,0 +1,160 @@
+package todo
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateData is what output templates are executed with, one todo at a
+// time. The fields and methods of the todo are available, e.g.
+// {{.Description.Text}}, {{.Line}} or {{.ID}}, along with its priority as
+// text and the values of its tags.
+type TemplateData struct {
+	*Todo
+	// The priority of the todo, or the one kept in the pri: tag of a
+	// complete todo, empty when it has none.
+	Priority  string
+	Projects  []string
+	Contexts  []string
+	Values    map[string]string
+	Due       *time.Time
+	Threshold *time.Time
+	Overdue   bool
+}
+
+// NewTemplateData returns the data of t output templates are executed with,
+// as seen on the date of now.
+func NewTemplateData(t *Todo, now time.Time) TemplateData {
+	r := NewRecord(t)
+	data := TemplateData{
+		Todo:     t,
+		Priority: r.Priority,
+		Projects: r.Projects,
+		Contexts: r.Contexts,
+		Values:   r.Values,
+		Overdue:  t.Overdue(now),
+	}
+	if due, ok := t.Due(); ok {
+		data.Due = &due
+	}
+	if threshold, ok := t.Threshold(); ok {
+		data.Threshold = &threshold
+	}
+	return data
+}
+
+var colors = map[string]string{
+	"bold": "1", "faint": "2", "underline": "4",
+	"black": "30", "red": "31", "green": "32", "yellow": "33",
+	"blue": "34", "magenta": "35", "cyan": "36", "white": "37",
+}
+
+// NewTemplate parses an output template, e.g.
+// {{.ID}} {{.Priority}} {{.Description.Text}} {{join .Projects ","}}
+//
+// Besides the functions of text/template, templates can call:
+//
+//	join list sep        the items of list separated by sep
+//	date layout d        the date d in the layout of package time, e.g. "Jan 2"
+//	relative d           the date d relative to the date of now, e.g. today,
+//	                     tomorrow, in 3 days or 2 days ago
+//	pad n s              s padded with spaces on the right to n characters
+//	padLeft n s          s padded with spaces on the left to n characters
+//	color name s         s in a terminal colour: black, red, green, yellow,
+//	                     blue, magenta, cyan, white, bold, faint or underline
+//
+// Dates are time.Time values, such as .CreationDate, pointers to them, such
+// as .Due, or YYYY-MM-DD text. Missing dates give empty text.
+func NewTemplate(text string, now time.Time) (*template.Template, error) {
+	today := dateOf(now)
+	funcs := template.FuncMap{
+		"join": strings.Join,
+		"date": func(layout string, d interface{}) (string, error) {
+			date, ok, err := templateDate(d)
+			if !ok || err != nil {
+				return "", err
+			}
+			return date.Format(layout), nil
+		},
+		"relative": func(d interface{}) (string, error) {
+			date, ok, err := templateDate(d)
+			if !ok || err != nil {
+				return "", err
+			}
+			return relativeDate(dateOf(date), today), nil
+		},
+		"pad": func(n int, s string) string {
+			return fmt.Sprintf("%-*s", n, s)
+		},
+		"padLeft": func(n int, s string) string {
+			return fmt.Sprintf("%*s", n, s)
+		},
+		"color": func(name, s string) (string, error) {
+			code, ok := colors[strings.ToLower(name)]
+			if !ok {
+				return "", fmt.Errorf("unknown color %q", name)
+			}
+			return "\x1b[" + code + "m" + s + "\x1b[0m", nil
+		},
+	}
+	return template.New("todo").Funcs(funcs).Parse(text)
+}
+
+// templateDate returns the date held by d, reporting false when there is
+// none.
+func templateDate(d interface{}) (time.Time, bool, error) {
+	switch v := d.(type) {
+	case time.Time:
+		return v, !v.IsZero(), nil
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, false, nil
+		}
+		return *v, !v.IsZero(), nil
+	case string:
+		if len(v) == 0 {
+			return time.Time{}, false, nil
+		}
+		date, err := time.Parse(YYYYMMDD, v)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("bad date %q, expected YYYY-MM-DD", v)
+		}
+		return date, true, nil
+	case nil:
+		return time.Time{}, false, nil
+	}
+	return time.Time{}, false, fmt.Errorf("%v isn't a date", d)
+}
+
+// relativeDate describes date as seen from today.
+func relativeDate(date, today time.Time) string {
+	days := int(date.Sub(today).Hours() / 24)
+	switch {
+	case days == 0:
+		return "today"
+	case days == 1:
+		return "tomorrow"
+	case days == -1:
+		return "yesterday"
+	case days > 0:
+		return fmt.Sprintf("in %d days", days)
+	}
+	return fmt.Sprintf("%d days ago", -days)
+}
+
+// WriteTemplate executes tmpl for every todo, as seen on the date of now,
+// writing each one to w on its own line.
+func WriteTemplate(w io.Writer, tmpl *template.Template, todos []*Todo, now time.Time) error {
+	for _, t := range todos {
+		if err := tmpl.Execute(w, NewTemplateData(t, now)); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package todo
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_Write_Template(t *testing.T) {
+	todos := loadTodos(t,
+		"(A) 2022-04-20 Call Mom +Family +Phone due:2022-04-26 id:3",
+		"x 2022-04-27 Pay rent +House pri:B due:2022-05-01",
+		"Water plants t:2022-04-30",
+	)
+
+	testcases := []struct{ text, expected string }{
+		{`{{.ID}} {{.Priority}} {{.Description.Text}} {{join .Projects ","}}`, "id:3 A Call Mom Family,Phone\n2 B Pay rent House\n3  Water plants \n"},
+		{`{{.Line}}|{{date "Jan 2" .CreationDate}}|{{date "2006/01/02" .Due}}|{{date "Jan 2" .Values.t}}`, "1|Apr 20|2022/04/26|\n2||2022/05/01|\n3|||Apr 30\n"},
+		{`{{relative .Due}}{{relative .Threshold}}{{if .Overdue}} overdue{{end}}`, "yesterday overdue\nin 4 days\nin 3 days\n"},
+		{`[{{pad 6 .ID}}][{{padLeft 3 .Priority}}]`, "[id:3  ][  A]\n[2     ][  B]\n[3     ][   ]\n"},
+		{`{{if .Done}}{{color "green" "x"}}{{else}}-{{end}}`, "-\n\x1b[32mx\x1b[0m\n-\n"},
+	}
+
+	for _, tc := range testcases {
+		tmpl, err := NewTemplate(tc.text, dueNow)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		if err := WriteTemplate(&b, tmpl, todos, dueNow); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); got != tc.expected {
+			t.Errorf("Template %q should give %q, but got: %q", tc.text, tc.expected, got)
+		}
+	}
+
+	for _, text := range []string{`{{color "pink" .ID}}`, `{{date "Jan 2" .Values.due}}{{date "Jan 2" .Description.Text}}`} {
+		tmpl, err := NewTemplate(text, dueNow)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		if err := WriteTemplate(&b, tmpl, todos, dueNow); err == nil {
+			t.Errorf("Template %q should fail, but got: %q", text, b.String())
+		}
+	}
+
+	if _, err := NewTemplate("{{.ID", dueNow); err == nil {
+		t.Error("Unterminated template should be rejected.")
+	}
+}
@@ -0,0 +1,44 @@
+package todo
+
+import (
+	"fmt"
+	"time"
+)
+
+// ThresholdKey is the key of the tag holding the date before which a todo
+// can't be started, e.g. t:2022-05-01
+const ThresholdKey = "t"
+
+// Threshold returns the threshold date of the todo, when its t: tag holds a date.
+func (t Todo) Threshold() (time.Time, bool) {
+	return t.dateValue(ThresholdKey)
+}
+
+// Hidden reports whether the todo's threshold date is after the date of now,
+// in which case it isn't listed by default.
+func (t Todo) Hidden(now time.Time) bool {
+	threshold, ok := t.Threshold()
+	return ok && threshold.After(dateOf(now))
+}
+
+// Snooze sets the threshold date of the todo, as seen on the date of now.
+// value is either a date ResolveDate knows, or a number of days, weeks,
+// months or years such as 3d or 1w pushing back the threshold, counted
+// from today when the todo isn't hidden.
+func (t *Todo) Snooze(value string, now time.Time) error {
+	if n, unit, ok := parseDuration(value); ok {
+		from := dateOf(now)
+		if threshold, ok := t.Threshold(); ok && threshold.After(from) {
+			from = threshold
+		}
+		t.SetValue(ThresholdKey, addDuration(from, n, unit).Format(YYYYMMDD))
+		return nil
+	}
+
+	date, ok := ResolveDate(value, now)
+	if !ok {
+		return fmt.Errorf("bad snooze value %q, expected a date or a duration such as 3d, 2w or 1m", value)
+	}
+	t.SetValue(ThresholdKey, date.Format(YYYYMMDD))
+	return nil
+}
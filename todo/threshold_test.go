@@ -0,0 +1,52 @@
+package todo
+
+import "testing"
+
+func Test_Hidden(t *testing.T) {
+	testcases := []struct {
+		input  string
+		hidden bool
+	}{
+		{"Call Mom t:2022-04-28", true},
+		{"Call Mom t:2022-04-27", false},
+		{"Call Mom t:2022-04-01", false},
+		{"Call Mom t:someday", false},
+		{"Call Mom", false},
+	}
+	for _, tc := range testcases {
+		todo, err := Parse(tc.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := todo.Hidden(dueNow); got != tc.hidden {
+			t.Errorf("%q should be hidden: %v, but got: %v", tc.input, tc.hidden, got)
+		}
+	}
+}
+
+func Test_Snooze(t *testing.T) {
+	testcases := []struct{ input, value, expected string }{
+		{"Call Mom", "3d", "Call Mom t:2022-04-30"},
+		{"Call Mom t:2022-05-10", "+1w", "Call Mom t:2022-05-17"},
+		{"Call Mom t:2022-04-01", "1w", "Call Mom t:2022-05-04"},
+		{"Call Mom t:2022-05-10 +Family", "tomorrow", "Call Mom t:2022-04-28 +Family"},
+		{"Call Mom", "2022-06-01", "Call Mom t:2022-06-01"},
+	}
+	for _, tc := range testcases {
+		todo, err := Parse(tc.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := todo.Snooze(tc.value, dueNow); err != nil {
+			t.Fatal(err)
+		}
+		if got := todo.Format(); got != tc.expected {
+			t.Errorf("Snoozing %q by %q should give %q, but got: %q", tc.input, tc.value, tc.expected, got)
+		}
+	}
+
+	todo, _ := Parse("Call Mom")
+	if err := todo.Snooze("later", dueNow); err == nil {
+		t.Errorf("Snoozing by %q should fail.", "later")
+	}
+}
@@ -5,8 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
-	"os"
 	"strings"
 )
 
@@ -20,20 +18,6 @@ func fileOrDefault(name string) string {
 	return fname
 }
 
-func AddToFile(todo *Todo) {
-	fname := fileOrDefault("")
-	f, err := os.OpenFile(fname, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		log.Println(err)
-	}
-	defer f.Close()
-
-	_, err = fmt.Fprintln(f, todo.Original)
-	if err != nil {
-		log.Println(err)
-	}
-}
-
 func GetFromFile(r io.Reader) ([]string, error) {
 	var b strings.Builder
 	scanner := bufio.NewScanner(r)
@@ -49,21 +33,40 @@ func GetFromFile(r io.Reader) ([]string, error) {
 	return strings.Split(b.String(), "\n"), nil
 }
 
+// GetAll parses every non-empty line read from r. Lines that fail to parse
+// are skipped and reported together as ParseErrors, each one carrying the
+// line number it was found at.
 func GetAll(r io.Reader) ([]*Todo, error) {
-	lines, err := GetFromFile(r)
-	if err != nil {
-		return nil, err
-	}
-
 	todos := make([]*Todo, 0)
-	for _, l := range lines {
+	var errs ParseErrors
+
+	scanner := bufio.NewScanner(r)
+	for i := 1; scanner.Scan(); i++ {
+		l := scanner.Text()
+		if len(strings.TrimSpace(l)) == 0 {
+			continue
+		}
+
 		t, err := Parse(l)
 		if err != nil {
-			log.Println(err)
-			return todos, err
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				return todos, err
+			}
+			perr.Line = i
+			errs = append(errs, perr)
+			continue
 		}
+		t.Line = i
 		todos = append(todos, t)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return todos, err
+	}
 
+	if len(errs) > 0 {
+		return todos, errs
 	}
 	return todos, nil
 }
@@ -88,22 +91,20 @@ func GetIncompleteLines(lines []string) []string {
 	return incomplete
 }
 
-func PrintAll() {
-	fname := fileOrDefault("")
-	f, err := os.Open(fname)
-	if err != nil {
-		log.Println(err)
-	}
-	defer f.Close()
-
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		fmt.Println(scanner.Text())
-	}
+// PrintLine prints a todo line prefixed with its line number, which
+// commands accept as the todo's ID.
+func PrintLine(n int, line string) {
+	fmt.Printf("%d %s\n", n, line)
+}
 
-	if err := scanner.Err(); err != nil {
-		fmt.Println(err)
+// PrintAll prints every todo of s. Lines that couldn't be parsed are
+// returned as ParseErrors.
+func PrintAll(s Store) error {
+	todos, err := s.Load()
+	for _, t := range todos {
+		PrintLine(t.Line, t.Original)
 	}
+	return err
 }
 
 func Contains(todos []*Todo, todo *Todo) bool {
@@ -115,82 +116,32 @@ func Contains(todos []*Todo, todo *Todo) bool {
 	return false
 }
 
-func todosFromFileByValue(f *os.File, value string) []*Todo {
-	var b strings.Builder
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		b.WriteString(scanner.Text() + "\n")
-	}
-
-	if err := scanner.Err(); err != nil {
-		fmt.Println(err)
-	}
-
-	lines := strings.Split(b.String(), "\n")
-	todos := make([]*Todo, 0)
-	for _, l := range lines {
-		if !strings.Contains(strings.ToLower(l), value) {
-			// todo doesn't contain this tag value
-			continue
-		}
-
-		t, err := Parse(l)
-		if err != nil {
-			log.Println(err)
-		}
-		todos = append(todos, t)
-
-	}
-	return todos
+// PrintByTag prints the todos of s having a tag of the given type whose
+// value contains value. Lines that couldn't be parsed are returned as
+// ParseErrors.
+func PrintByTag(s Store, tag TagType, value string) error {
+	return printMatching(s, TagValueFilter(tag, value))
 }
 
-func PrintByTag(tag TagType, value string) {
-	fname := fileOrDefault("")
-	f, err := os.Open(fname)
-	if err != nil {
-		log.Println(err)
-	}
-	defer f.Close()
-	formattedVal := strings.ToLower(value)
-	todos := todosFromFileByValue(f, formattedVal)
-
-	filteredTodos := make([]*Todo, 0)
-	for _, todo := range todos {
-		for _, tg := range todo.Description.Tags {
-			if tg.TagType == tag && strings.Contains(strings.ToLower(tg.Value), formattedVal) && !Contains(filteredTodos, todo) {
-				filteredTodos = append(filteredTodos, todo)
-			}
-		}
-	}
-
-	for _, f := range filteredTodos {
-		fmt.Println(f.Original)
-	}
+// PrintByKVTag prints the todos of s having a key value tag whose key
+// contains key. Lines that couldn't be parsed are returned as ParseErrors.
+func PrintByKVTag(s Store, key string) error {
+	return printMatching(s, KeyFilter(key))
 }
 
-func PrintByKVTag(key string) {
-	fname := fileOrDefault("")
-	f, err := os.Open(fname)
-	if err != nil {
-		log.Println(err)
+// printMatching prints the todos of s match selects.
+func printMatching(s Store, match Filter) error {
+	todos, err := s.Load()
+	if _, ok := err.(ParseErrors); err != nil && !ok {
+		return err
 	}
-	defer f.Close()
-
-	formattedKey := strings.ToLower(key)
-	todos := todosFromFileByValue(f, formattedKey)
 
-	filteredTodos := make([]*Todo, 0)
-	for _, todo := range todos {
-		for _, tg := range todo.Description.Tags {
-			if tg.TagType == KeyValue && strings.Contains(strings.ToLower(*tg.Key), formattedKey) && !Contains(filteredTodos, todo) {
-				filteredTodos = append(filteredTodos, todo)
-			}
+	for _, t := range todos {
+		if match(t) {
+			PrintLine(t.Line, t.Original)
 		}
 	}
-
-	for _, f := range filteredTodos {
-		fmt.Println(f.Original)
-	}
+	return err
 }
 
 func FindByDescrText(todos []Todo, text string) *Todo {
@@ -249,39 +200,3 @@ func WriteAll(w io.Writer, lines []string) error {
 	}
 	return nil
 }
-
-func DeleteFirst(file io.Reader, text string) error {
-	lines, err := SkipFirst(file, text)
-	if len(lines) < 1 || err != nil {
-		fmt.Println("couldn't parse todo file")
-		log.Fatal("couldn't parse todo file")
-	}
-
-	if err != nil {
-		return err
-	}
-
-	// Write lines to new tmp file skipping the first occurrence
-	tmp, err := os.Create("copy.tmp")
-	if err != nil {
-		panic(err)
-	}
-	defer tmp.Close()
-
-	if err := WriteAll(tmp, lines); err != nil {
-		log.Fatal(err)
-	}
-
-	// Replace old file with new file
-	if osf, ok := file.(*os.File); ok {
-		if err := os.Rename("copy.tmp", osf.Name()); err != nil {
-			return err
-		}
-	}
-
-	if err := tmp.Close(); err != nil {
-		log.Fatal(err)
-	}
-
-	return nil
-}
@@ -36,6 +36,16 @@ func todoLiterals() []string {
 	}
 }
 
+// loadTodos returns the todos of lines, numbered by their line, failing the
+// test when a line isn't a valid todo.
+func loadTodos(t *testing.T, lines ...string) []*Todo {
+	todos, err := GetAll(strings.NewReader(strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return todos
+}
+
 func Test_Skip_First_Occurrence(t *testing.T) {
 	testcases := []struct{ query, line string }{
 		{"Mom", "x 2011-03-03 Call Mom due:now"},
@@ -119,3 +129,26 @@ func Test_Filter_By_Completion_Status(t *testing.T) {
 	})
 
 }
+
+func Test_Get_All_Collects_Parse_Errors(t *testing.T) {
+	lines := []string{
+		"(A) Call Mom @phone",
+		"2022-13-01 bad date",
+		"",
+		"2011-03-02 Document +TodoTxt task format",
+		"x 2015-13-20 bad date",
+	}
+
+	todos, err := GetAll(strings.NewReader(strings.Join(lines, "\n")))
+	if len(todos) != 2 {
+		t.Errorf("Valid lines should still be parsed. Expected 2 todos, but got: %d", len(todos))
+	}
+
+	errs, ok := err.(ParseErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("Expected 2 parse errors, but got: %v", err)
+	}
+	if errs[0].Line != 2 || errs[1].Line != 5 {
+		t.Errorf("Parse errors have wrong line numbers: %d, %d", errs[0].Line, errs[1].Line)
+	}
+}